@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tsingsun/woocoo"
+)
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+// Checker checks the health of a component. Components such as cache, redis client, database pool and registry
+// can contribute to the application health by implementing it. For example:
+//
+//	srv.AddChecker("redis", redisClient)                   // redisx.Client
+//	srv.AddChecker("cache", redisCache)                    // redisc.Redisc
+//	srv.AddChecker("db", admin.CheckerFunc(db.PingContext)) // *sql.DB from sqlx
+//	srv.AddChecker("registry", grpcServer)                 // grpcx.Server, the registration to registry
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an adapter to allow the use of ordinary functions as Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx).
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+type (
+	namedChecker struct {
+		name    string
+		checker Checker
+	}
+	namedReadiness struct {
+		name      string
+		readiness woocoo.Readiness
+	}
+	// CheckResult is the result of a health or readiness check.
+	CheckResult struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks,omitempty"`
+	}
+)
+
+// runChecks runs all checks concurrently and aggregate the results.
+func runChecks(ctx context.Context, checks map[string]func(ctx context.Context) error) (res CheckResult, ok bool) {
+	res = CheckResult{
+		Status: statusOK,
+		Checks: make(map[string]string, len(checks)),
+	}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	ok = true
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := statusOK
+			if err := check(ctx); err != nil {
+				status = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			res.Checks[name] = status
+			if status != statusOK {
+				ok = false
+			}
+		}()
+	}
+	wg.Wait()
+	if !ok {
+		res.Status = statusFail
+	}
+	return res, ok
+}
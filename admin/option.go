@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"github.com/tsingsun/woocoo"
+	"github.com/tsingsun/woocoo/pkg/conf"
+)
+
+// Option the function to apply a configuration option
+type Option func(s *ServerOptions)
+
+// WithConfiguration set up the configuration of the admin server by a configuration instance, usually it is the
+// `admin` section of the application configuration.
+func WithConfiguration(cfg *conf.Configuration) Option {
+	return func(s *ServerOptions) {
+		s.configuration = cfg
+	}
+}
+
+// WithChecker adds a named health checker.
+func WithChecker(name string, checker Checker) Option {
+	return func(s *ServerOptions) {
+		s.checkers = append(s.checkers, namedChecker{name: name, checker: checker})
+	}
+}
+
+// WithReadiness adds a named readiness target such as web.Server or grpcx.Server.
+func WithReadiness(name string, r woocoo.Readiness) Option {
+	return func(s *ServerOptions) {
+		s.readiness = append(s.readiness, namedReadiness{name: name, readiness: r})
+	}
+}
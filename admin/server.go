@@ -0,0 +1,290 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"regexp"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/tsingsun/woocoo"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/pkg/log"
+)
+
+const (
+	// defaultAddr only listens on the loopback interface, the admin endpoints should not be exposed to the public.
+	defaultAddr         = "127.0.0.1:9990"
+	defaultCheckTimeout = time.Second * 5
+)
+
+var (
+	logger = log.Component("admin")
+
+	ErrServerNotReady = errors.New("admin: server is not ready")
+	ErrDraining       = errors.New("admin: application is draining")
+
+	defaultRedactKeys = []string{"password", "secret", "key", "token"}
+)
+
+// ServerOptions is the options of admin server.
+type ServerOptions struct {
+	// Addr is the listen address, default is 127.0.0.1:9990.
+	Addr string `json:"addr" yaml:"addr"`
+	// Pprof enables the `/debug/pprof/*` endpoints, default is false.
+	Pprof bool `json:"pprof" yaml:"pprof"`
+	// Config enables the `/config` endpoint which dumps the redacted application configuration, default is false.
+	Config bool `json:"config" yaml:"config"`
+	// LogLevel enables the `/loglevel` endpoint which gets or sets the level of the global logger,
+	// and the `/loglevel/components` endpoint for the levels of component loggers, default is false.
+	LogLevel bool `json:"logLevel" yaml:"logLevel"`
+	// RedactKeys are the case-insensitive substrings of the configuration keys whose value will be masked.
+	RedactKeys []string `json:"redactKeys" yaml:"redactKeys"`
+	// CheckTimeout is the timeout for each health and readiness check.
+	CheckTimeout time.Duration `json:"checkTimeout" yaml:"checkTimeout"`
+
+	configuration *conf.Configuration
+	checkers      []namedChecker
+	readiness     []namedReadiness
+}
+
+// Server is an ops server which exposes health, readiness, pprof, configuration and log level endpoints.
+//
+// Configuration example:
+//
+//	admin:
+//	  addr: 127.0.0.1:9990
+//	  pprof: true
+//	  config: true
+//	  logLevel: true
+//	  redactKeys: [password, secret, key, token]
+//	  checkTimeout: 5s
+//
+// Endpoints:
+//   - GET /healthz: aggregates the results of all Checker.
+//   - GET /readyz: aggregates the results of all Checker and Readiness, fails after Drain.
+//   - GET /debug/pprof/*: the net/http/pprof handlers.
+//   - GET /config: the redacted dump of the application configuration.
+//   - GET /loglevel: returns the level of the global logger, PUT /loglevel?level=debug changes it.
+//   - GET /loglevel/components: returns the levels of component loggers, PUT /loglevel/components?name=grpc&level=debug
+//     changes the level of a component.
+//
+// The endpoints have no authentication. pprof, config and loglevel expose the runtime details and allow changing the
+// log level, so they are disabled unless configured, and the server listens on the loopback interface by default.
+// If you listen on other interfaces, protect the address by the network policy.
+type Server struct {
+	opts    ServerOptions
+	mux     *http.ServeMux
+	httpSrv *http.Server
+	ready   atomic.Bool
+	// draining indicates the application is draining, readiness is failing.
+	draining atomic.Bool
+}
+
+// New creates an admin server.
+func New(opts ...Option) *Server {
+	s := &Server{
+		opts: ServerOptions{
+			Addr:         defaultAddr,
+			RedactKeys:   slices.Clone(defaultRedactKeys),
+			CheckTimeout: defaultCheckTimeout,
+		},
+		mux: http.NewServeMux(),
+	}
+	for _, o := range opts {
+		o(&s.opts)
+	}
+	if s.opts.configuration != nil {
+		if err := s.Apply(s.opts.configuration); err != nil {
+			panic(err)
+		}
+	}
+	s.registerRoutes()
+	s.httpSrv = &http.Server{
+		Addr:    s.opts.Addr,
+		Handler: s.mux,
+	}
+	return s
+}
+
+// Apply implement conf.Configurable
+func (s *Server) Apply(cfg *conf.Configuration) error {
+	return cfg.Unmarshal(&s.opts)
+}
+
+// ServerOptions return a setting used by admin server
+func (s *Server) ServerOptions() ServerOptions {
+	return s.opts
+}
+
+// AddChecker adds a named health checker, it also affects the readiness.
+func (s *Server) AddChecker(name string, checker Checker) {
+	s.opts.checkers = append(s.opts.checkers, namedChecker{name: name, checker: checker})
+}
+
+// AddReadiness adds a named readiness target, it only affects the readiness.
+func (s *Server) AddReadiness(name string, r woocoo.Readiness) {
+	s.opts.readiness = append(s.opts.readiness, namedReadiness{name: name, readiness: r})
+}
+
+// HandleFunc registers a custom handler for the given pattern.
+func (s *Server) HandleFunc(pattern string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// Handler returns the http handler of admin server.
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+func (s *Server) registerRoutes() {
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	s.mux.HandleFunc("GET /readyz", s.handleReady)
+	if s.opts.Pprof {
+		s.mux.HandleFunc("/debug/pprof/", pprof.Index)
+		s.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		s.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		s.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		s.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	if s.opts.Config {
+		s.mux.HandleFunc("GET /config", s.handleConfig)
+	}
+	if s.opts.LogLevel {
+		s.mux.HandleFunc("GET /loglevel", s.handleGetLogLevel)
+		s.mux.HandleFunc("PUT /loglevel", s.handleSetLogLevel)
+		s.mux.HandleFunc("POST /loglevel", s.handleSetLogLevel)
+		s.mux.Handle("/loglevel/components", log.ComponentLevelHandler())
+	}
+}
+
+func (s *Server) healthChecks() map[string]func(ctx context.Context) error {
+	checks := make(map[string]func(ctx context.Context) error, len(s.opts.checkers))
+	for _, c := range s.opts.checkers {
+		checks[c.name] = c.checker.Check
+	}
+	return checks
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.opts.CheckTimeout)
+	defer cancel()
+	res, ok := runChecks(ctx, s.healthChecks())
+	writeCheckResult(w, res, ok)
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.opts.CheckTimeout)
+	defer cancel()
+	checks := s.healthChecks()
+	for _, rd := range s.opts.readiness {
+		checks[rd.name] = rd.readiness.Ready
+	}
+	if s.draining.Load() {
+		checks["admin"] = func(context.Context) error {
+			return ErrDraining
+		}
+	}
+	res, ok := runChecks(ctx, checks)
+	writeCheckResult(w, res, ok)
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, _ *http.Request) {
+	var settings map[string]any
+	if s.opts.configuration != nil {
+		settings = s.opts.configuration.Root().AllSettings()
+	} else {
+		settings = conf.AllSettings()
+	}
+	patterns := make([]string, len(s.opts.RedactKeys))
+	for i, k := range s.opts.RedactKeys {
+		patterns[i] = regexp.QuoteMeta(k)
+	}
+	writeJSON(w, http.StatusOK, conf.RedactMap(settings, patterns...))
+}
+
+func (s *Server) handleGetLogLevel(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"level": log.Global().Logger(log.WithOriginalLogger()).Level().String()})
+}
+
+func (s *Server) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	level := r.FormValue("level")
+	if level == "" && r.Body != nil {
+		var body struct {
+			Level string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+			level = body.Level
+		}
+	}
+	if err := log.Global().Logger(log.WithOriginalLogger()).SetLevel(level); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	logger.Info("log level changed", zap.String("level", level))
+	writeJSON(w, http.StatusOK, map[string]string{"level": level})
+}
+
+// Ready implements woocoo.Readiness, the server is ready after it starts listening.
+func (s *Server) Ready(context.Context) error {
+	if !s.ready.Load() {
+		return ErrServerNotReady
+	}
+	return nil
+}
+
+// Drain implements woocoo.Drainer, it flips the readiness to failing. The server keeps serving until Stop,
+// so that the orchestrator can observe the failing readiness during the drain period.
+func (s *Server) Drain(context.Context) error {
+	s.draining.Store(true)
+	return nil
+}
+
+// Start the admin server.
+func (s *Server) Start(context.Context) error {
+	ln, err := net.Listen("tcp", s.opts.Addr)
+	if err != nil {
+		return err
+	}
+	s.opts.Addr = ln.Addr().String()
+	logger.Info(fmt.Sprintf("listening and serving admin HTTP on %s", s.opts.Addr))
+	s.ready.Store(true)
+	err = s.httpSrv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Stop the admin server.
+func (s *Server) Stop(ctx context.Context) error {
+	s.ready.Store(false)
+	if err := s.httpSrv.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func writeCheckResult(w http.ResponseWriter, res CheckResult, ok bool) {
+	code := http.StatusOK
+	if !ok {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, res)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error("admin write response error", zap.Error(err))
+	}
+}
@@ -0,0 +1,230 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/pkg/log"
+	"github.com/tsingsun/woocoo/pkg/store/redisx"
+	"github.com/tsingsun/woocoo/rpc/grpcx"
+	"github.com/tsingsun/woocoo/rpc/grpcx/registry"
+	"github.com/tsingsun/woocoo/test/wctest"
+	"github.com/tsingsun/woocoo/web"
+
+	mock "github.com/tsingsun/woocoo/test/mock/registry"
+)
+
+type readiness struct {
+	err error
+}
+
+func (r readiness) Ready(context.Context) error {
+	return r.err
+}
+
+func doRequest(srv *Server, method, target string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, r)
+	return w
+}
+
+func TestNew(t *testing.T) {
+	cnf := conf.NewFromBytes([]byte(`
+admin:
+  addr: 127.0.0.1:0
+  pprof: false
+  redactKeys: [password]
+  checkTimeout: 1s
+`))
+	srv := New(WithConfiguration(cnf.Sub("admin")))
+	opts := srv.ServerOptions()
+	assert.Equal(t, "127.0.0.1:0", opts.Addr)
+	assert.False(t, opts.Pprof)
+	assert.False(t, opts.Config)
+	assert.Equal(t, []string{"password"}, opts.RedactKeys)
+	assert.Equal(t, []string{"password", "secret", "key", "token"}, defaultRedactKeys)
+	assert.Equal(t, time.Second, opts.CheckTimeout)
+	assert.Equal(t, http.StatusNotFound, doRequest(srv, http.MethodGet, "/debug/pprof/", "").Code)
+
+	t.Run("default", func(t *testing.T) {
+		srv := New()
+		opts := srv.ServerOptions()
+		assert.Equal(t, "127.0.0.1:9990", opts.Addr)
+		assert.False(t, opts.Pprof || opts.Config || opts.LogLevel)
+		for _, target := range []string{"/debug/pprof/", "/config", "/loglevel", "/loglevel/components"} {
+			assert.Equal(t, http.StatusNotFound, doRequest(srv, http.MethodGet, target, "").Code, target)
+		}
+	})
+	t.Run("enabled", func(t *testing.T) {
+		srv := New(WithConfiguration(conf.NewFromStringMap(map[string]any{
+			"pprof": true, "config": true, "logLevel": true,
+		})))
+		for _, target := range []string{"/debug/pprof/", "/config", "/loglevel", "/loglevel/components"} {
+			assert.Equal(t, http.StatusOK, doRequest(srv, http.MethodGet, target, "").Code, target)
+		}
+	})
+}
+
+func TestServer_Health(t *testing.T) {
+	rds := miniredis.RunT(t)
+	cli, err := redisx.NewClient(conf.NewFromStringMap(map[string]any{"addrs": []string{rds.Addr()}}))
+	require.NoError(t, err)
+	srv := New(WithChecker("redis", cli))
+
+	t.Run("healthy", func(t *testing.T) {
+		w := doRequest(srv, http.MethodGet, "/healthz", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var res CheckResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, statusOK, res.Status)
+		assert.Equal(t, statusOK, res.Checks["redis"])
+	})
+	t.Run("unhealthy", func(t *testing.T) {
+		srv.AddChecker("db", CheckerFunc(func(ctx context.Context) error {
+			return errors.New("db down")
+		}))
+		w := doRequest(srv, http.MethodGet, "/healthz", "")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var res CheckResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, statusFail, res.Status)
+		assert.Equal(t, "db down", res.Checks["db"])
+	})
+}
+
+func TestServer_Ready(t *testing.T) {
+	srv := New(WithReadiness("web", readiness{}))
+	assert.Equal(t, http.StatusOK, doRequest(srv, http.MethodGet, "/readyz", "").Code)
+	srv.AddReadiness("grpc", readiness{err: errors.New("not ready")})
+	w := doRequest(srv, http.MethodGet, "/readyz", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "not ready")
+	// health is not affected by readiness
+	assert.Equal(t, http.StatusOK, doRequest(srv, http.MethodGet, "/healthz", "").Code)
+
+	t.Run("draining", func(t *testing.T) {
+		srv := New(WithReadiness("web", readiness{}))
+		require.NoError(t, srv.Drain(context.Background()))
+		w := doRequest(srv, http.MethodGet, "/readyz", "")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), ErrDraining.Error())
+		assert.Equal(t, http.StatusOK, doRequest(srv, http.MethodGet, "/healthz", "").Code)
+	})
+}
+
+func TestServer_Config(t *testing.T) {
+	cnf := conf.NewFromBytes([]byte(`
+appName: test
+store:
+  db:
+    dsn: root:123@tcp(localhost)
+    password: "123"
+  redis:
+    - addrs: [localhost:6379]
+      authToken: abc
+admin:
+  addr: 127.0.0.1:0
+  config: true
+`))
+	srv := New(WithConfiguration(cnf.Sub("admin")))
+	w := doRequest(srv, http.MethodGet, "/config", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `"appName":"test"`)
+	assert.Contains(t, body, `"dsn":"root:123@tcp(localhost)"`)
+	assert.Contains(t, body, `"password":"******"`)
+	assert.Contains(t, body, `"authToken":"******"`)
+}
+
+func TestServer_LogLevel(t *testing.T) {
+	wctest.InitGlobalLogger(true)
+	srv := New(WithConfiguration(conf.NewFromStringMap(map[string]any{"logLevel": true})))
+	w := doRequest(srv, http.MethodGet, "/loglevel", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "debug")
+
+	w = doRequest(srv, http.MethodPut, "/loglevel?level=warn", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "warn", log.Global().Logger(log.WithOriginalLogger()).Level().String())
+
+	w = doRequest(srv, http.MethodPost, "/loglevel", `{"level":"info"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "info", log.Global().Logger(log.WithOriginalLogger()).Level().String())
+
+	w = doRequest(srv, http.MethodPut, "/loglevel?level=wrong", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doRequest(srv, http.MethodPut, "/loglevel/components?name=admin&level=error", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	defer log.SetComponentLevel("admin", "") //nolint:errcheck
+	w = doRequest(srv, http.MethodGet, "/loglevel/components", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"admin":"error"`)
+}
+
+func TestServer_Run(t *testing.T) {
+	srv := New(WithConfiguration(conf.NewFromStringMap(map[string]any{"addr": "127.0.0.1:0"})))
+	err := wctest.RunWait(t.Log, time.Millisecond*200, func() error {
+		return srv.Start(context.Background())
+	}, func() error {
+		time.Sleep(time.Millisecond * 100)
+		assert.NoError(t, srv.Ready(context.Background()))
+		resp, err := http.Get("http://" + srv.ServerOptions().Addr + "/healthz")
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		return srv.Stop(context.Background())
+	})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, srv.Ready(context.Background()), ErrServerNotReady)
+}
+
+func TestServer_App(t *testing.T) {
+	mock.RegisterDriver(map[string]*registry.ServiceInfo{})
+	cnf := conf.NewFromBytes([]byte(`
+web:
+  server:
+    addr: 127.0.0.1:0
+grpc:
+  server:
+    addr: 127.0.0.1:0
+  registry:
+    scheme: mock
+admin:
+  addr: 127.0.0.1:0
+`))
+	app := woocoo.New(woocoo.WithAppConfiguration(cnf))
+	websrv := web.New(web.WithConfiguration(cnf.Sub("web")))
+	grpcsrv := grpcx.New(grpcx.WithConfiguration(cnf.Sub("grpc")))
+	srv := New(WithConfiguration(cnf.Sub("admin")),
+		WithReadiness("web", websrv), WithReadiness("grpc", grpcsrv), WithChecker("registry", grpcsrv))
+	app.RegisterServer(websrv, grpcsrv, srv)
+	app.DependsOn(srv, websrv, grpcsrv)
+	err := wctest.RunWait(t.Log, time.Second, app.Run, func() error {
+		assert.Eventually(t, func() bool {
+			return doRequest(srv, http.MethodGet, "/readyz", "").Code == http.StatusOK
+		}, time.Second, time.Millisecond*10)
+		var res CheckResult
+		require.NoError(t, json.Unmarshal(doRequest(srv, http.MethodGet, "/readyz", "").Body.Bytes(), &res))
+		assert.Equal(t, map[string]string{"web": statusOK, "grpc": statusOK, "registry": statusOK}, res.Checks)
+		return app.Stop()
+	})
+	assert.NoError(t, err)
+}
@@ -3,9 +3,9 @@ package woocoo
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
 	"time"
 
@@ -21,20 +21,39 @@ type Server interface {
 	Stop(ctx context.Context) error
 }
 
+// Readiness is an optional interface for Server. App uses it to know when a Server is able to serve,
+// so that the servers depending on it can be started afterward.
+type Readiness interface {
+	// Ready reports whether the server is ready to serve, returns nil if ready. It should return quickly
+	// because App polls it until ready or the start timeout exceeded.
+	Ready(ctx context.Context) error
+}
+
+// Drainer is an optional interface for Server. Before stopping any Server, App calls Drain on all servers, then
+// waits the drain period for in-flight requests to finish. A Server should stop accepting new traffic from
+// service discovery and report not ready in Drain, but keep serving until Stop.
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
 // App is the application with a universal mechanism to manage goroutine lifecycles.
 type App struct {
 	opts options
 
 	ctx    context.Context
 	cancel func()
+	// logger is the global logger built by the configuration, it is closed after stopping.
+	logger *log.Logger
 }
 
 // New creates an application by Option.
 func New(opts ...Option) *App {
 	app := &App{}
 	app.opts = options{
-		quitCh:      []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT},
-		StopTimeout: time.Second * 5,
+		quitCh:       []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT},
+		reloadCh:     []os.Signal{syscall.SIGHUP},
+		StopTimeout:  time.Second * 5,
+		StartTimeout: defaultStartTimeout,
 	}
 	for _, opt := range opts {
 		opt(&app.opts)
@@ -48,6 +67,15 @@ func New(opts ...Option) *App {
 	if app.opts.cnf.IsSet("log") {
 		ll := log.NewFromConf(app.opts.cnf.Sub("log"))
 		ll.AsGlobal()
+		app.logger = ll
+		app.opts.cnf.Subscribe("log", func(_, cnf *conf.Configuration) {
+			if cnf == nil {
+				return
+			}
+			if err := ll.ReloadLevels(cnf); err != nil {
+				log.Errorf("reload log levels error: %v", err)
+			}
+		})
 	} else {
 		log.InitGlobalLogger() // reset global logger, assign component logger.
 	}
@@ -63,30 +91,63 @@ func (a *App) RegisterServer(servers ...Server) {
 	a.opts.servers = append(a.opts.servers, servers...)
 }
 
+// DependsOn declares that srv depends on deps, all of them must be registered by RegisterServer.
+//
+// App starts srv after every dependency reports ready (see Readiness), and stops srv before them.
+// A dependency that does not implement Readiness is considered ready once it has been started.
+func (a *App) DependsOn(srv Server, deps ...Server) {
+	if a.opts.deps == nil {
+		a.opts.deps = make(map[Server][]Server)
+	}
+	a.opts.deps[srv] = append(a.opts.deps[srv], deps...)
+}
+
 // Run all Server concurrently.
+//
+// Servers are started in topological order of their dependencies, a Server is started after all its
+// dependencies are ready, and they are stopped in reverse order.
 // Run returns when all Server have exited.
 // Run returns the first non-nil error (if any) from them.
+//
+// Lifecycle hooks are called in the order:
+//   - BeforeStart hooks before any Server starting, an error aborts Run.
+//   - AfterStart hooks after all Server started and ready, an error stops the App.
+//   - BeforeStop hooks before stopping servers, AfterStop hooks after all servers stopped.
+//     Stop hooks always run all with the StopTimeout context, and their errors are joined.
+//
+// Stopping waits for the starting to finish or be cancelled, then runs a coordinated drain sequence with the StopTimeout context: drains all servers which implement Drainer,
+// waits the drain period, then stops the servers in reverse order, and flushes the logger by Sync and closes its async cores at last.
 func (a *App) Run() error {
+	servers, err := a.sortServers()
+	if err != nil {
+		return err
+	}
+	if err = runHooks(a.ctx, a.opts.beforeStart, false); err != nil {
+		return err
+	}
 	eg, ctx := errgroup.WithContext(a.ctx)
-	wg := sync.WaitGroup{}
-	for _, srv := range a.opts.servers {
-		srv := srv
-		eg.Go(func() error {
-			<-ctx.Done()
-			stopCtx, cancel := context.WithTimeout(context.Background(), a.opts.StopTimeout)
-			defer cancel()
-			return srv.Stop(stopCtx)
-		})
-		wg.Add(1)
-		eg.Go(func() error {
-			wg.Done()
-			return srv.Start(a.ctx)
-		})
-		if a.opts.interval > 0 {
-			time.Sleep(a.opts.interval)
+	var (
+		started []Server
+		// startDone is closed after the start phase finished or was cancelled, started is not changed after it.
+		startDone = make(chan struct{})
+	)
+	eg.Go(func() error {
+		<-ctx.Done()
+		<-startDone
+		list := started
+		stopCtx, cancel := context.WithTimeout(context.Background(), a.opts.StopTimeout)
+		defer cancel()
+		err := runHooks(stopCtx, a.opts.beforeStop, true)
+		err = errors.Join(err, drainServers(stopCtx, list, a.opts.drainPeriod))
+		err = errors.Join(err, stopServers(stopCtx, list))
+		err = errors.Join(err, runHooks(stopCtx, a.opts.afterStop, true))
+		// flush the buffered log entries and close the async cores at last.
+		_ = a.Sync()
+		if a.logger != nil {
+			err = errors.Join(err, a.logger.Close())
 		}
-	}
-	wg.Wait()
+		return err
+	})
 	if len(a.opts.quitCh) == 0 {
 		eg.Go(func() error {
 			<-ctx.Done()
@@ -95,6 +156,7 @@ func (a *App) Run() error {
 	} else {
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, a.opts.quitCh...)
+		defer signal.Stop(quit)
 		eg.Go(func() error {
 			select {
 			case <-ctx.Done():
@@ -104,12 +166,180 @@ func (a *App) Run() error {
 			}
 		})
 	}
+	if len(a.opts.reloadCh) > 0 && a.opts.cnf != nil {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, a.opts.reloadCh...)
+		defer signal.Stop(reload)
+		eg.Go(func() error {
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-reload:
+					a.reloadConfiguration()
+				}
+			}
+		})
+	}
+	eg.Go(func() error {
+		defer close(startDone)
+		for i, srv := range servers {
+			for _, dep := range a.opts.deps[srv] {
+				if err := waitReady(ctx, dep, a.opts.StartTimeout); err != nil {
+					return err
+				}
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			started = append(started, srv)
+			eg.Go(func() error {
+				return srv.Start(a.ctx)
+			})
+			if a.opts.interval > 0 && i < len(servers)-1 {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(a.opts.interval):
+				}
+			}
+		}
+		if len(a.opts.afterStart) == 0 {
+			return nil
+		}
+		for _, srv := range servers {
+			if err := waitReady(ctx, srv, a.opts.StartTimeout); err != nil {
+				return err
+			}
+		}
+		return runHooks(ctx, a.opts.afterStart, false)
+	})
 	if err := eg.Wait(); err != nil && !errors.Is(err, context.Canceled) {
 		return err
 	}
 	return nil
 }
 
+// sortServers returns the registered servers in topological order of dependencies. Servers without
+// dependency relationship keep the registration order.
+func (a *App) sortServers() ([]Server, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	registered := make(map[Server]bool, len(a.opts.servers))
+	for _, srv := range a.opts.servers {
+		registered[srv] = true
+	}
+	state := make(map[Server]int, len(a.opts.servers))
+	sorted := make([]Server, 0, len(a.opts.servers))
+	var visit func(srv Server) error
+	visit = func(srv Server) error {
+		switch state[srv] {
+		case visiting:
+			return fmt.Errorf("app: cyclic dependency found at server %T", srv)
+		case visited:
+			return nil
+		}
+		state[srv] = visiting
+		for _, dep := range a.opts.deps[srv] {
+			if !registered[dep] {
+				return fmt.Errorf("app: dependency %T of server %T is not registered", dep, srv)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[srv] = visited
+		sorted = append(sorted, srv)
+		return nil
+	}
+	for _, srv := range a.opts.servers {
+		if err := visit(srv); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// waitReady polls the server until it is ready, the timeout exceeded or ctx is done.
+func waitReady(ctx context.Context, srv Server, timeout time.Duration) error {
+	rd, ok := srv.(Readiness)
+	if !ok {
+		return nil
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+	for {
+		err := rd.Ready(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("app: server %T is not ready: %w", srv, errors.Join(err, ctx.Err()))
+		case <-ticker.C:
+		}
+	}
+}
+
+// runHooks calls hooks in order. If all is false, it returns at the first error, otherwise all hooks are called
+// and the errors are joined.
+func runHooks(ctx context.Context, hooks []Hook, all bool) (err error) {
+	for _, hook := range hooks {
+		if herr := hook(ctx); herr != nil {
+			if !all {
+				return herr
+			}
+			err = errors.Join(err, herr)
+		}
+	}
+	return err
+}
+
+// drainServers drains the started servers in reverse order, then waits the drain period or until ctx is done.
+func drainServers(ctx context.Context, servers []Server, period time.Duration) (err error) {
+	for i := len(servers) - 1; i >= 0; i-- {
+		if dr, ok := servers[i].(Drainer); ok {
+			err = errors.Join(err, dr.Drain(ctx))
+		}
+	}
+	if period <= 0 {
+		return err
+	}
+	timer := time.NewTimer(period)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return err
+}
+
+// stopServers stops the started servers in reverse order.
+func stopServers(ctx context.Context, servers []Server) (err error) {
+	for i := len(servers) - 1; i >= 0; i-- {
+		err = errors.Join(err, servers[i].Stop(ctx))
+	}
+	return err
+}
+
+// reloadConfiguration reloads the application configuration and notifies the subscribers,
+// keeps the current configuration if failed.
+func (a *App) reloadConfiguration() {
+	if err := a.opts.cnf.TryReload(); err != nil {
+		log.Errorf("reload configuration error: %v", err)
+		return
+	}
+	log.Info("configuration reloaded")
+}
+
 // Stop the application.
 func (a *App) Stop() error {
 	a.cancel()
@@ -1,14 +1,23 @@
 package woocoo
 
 import (
+	"context"
 	"os"
 	"time"
 
 	"github.com/tsingsun/woocoo/pkg/conf"
 )
 
+const (
+	defaultStartTimeout   = time.Second * 30
+	readinessPollInterval = time.Millisecond * 50
+)
+
 type Option func(o *options)
 
+// Hook is a function called at a lifecycle stage of App.
+type Hook func(ctx context.Context) error
+
 type options struct {
 	cnf *conf.AppConfiguration
 	// Wait for interrupt signal to gracefully runAndClose the server with
@@ -17,12 +26,25 @@ type options struct {
 	// kill -2 is syscall.SIGINT
 	// kill -9 is syscall.SIGKILL but can't be caught, so don't need add it
 	quitCh []os.Signal
+	// reloadCh is the signals to reload the configuration, default is syscall.SIGHUP
+	reloadCh []os.Signal
 
 	servers []Server
+	// deps is the dependencies of server, key is the dependent server.
+	deps map[Server][]Server
 	// interval time for App starting every server with time.Sleep in the server slice.
 	interval time.Duration
 	// StopTimeout is the timeout for stopping the server.
 	StopTimeout time.Duration
+	// drainPeriod is the time waiting for in-flight requests to finish after servers drained.
+	drainPeriod time.Duration
+	// StartTimeout is the max time waiting for a Server which implements Readiness to be ready.
+	StartTimeout time.Duration
+
+	beforeStart []Hook
+	afterStart  []Hook
+	beforeStop  []Hook
+	afterStop   []Hook
 }
 
 // WithAppConfiguration set up the configuration of the web server by a configuration instance
@@ -38,3 +60,63 @@ func WithInterval(interval time.Duration) Option {
 		s.interval = interval
 	}
 }
+
+// WithStartTimeout sets the max time to wait for a dependency server to be ready before starting the servers depending on it.
+// zero means no timeout. Default is 30 seconds.
+func WithStartTimeout(timeout time.Duration) Option {
+	return func(s *options) {
+		s.StartTimeout = timeout
+	}
+}
+
+// WithDrainPeriod sets the time to wait for in-flight requests to finish between draining and stopping servers.
+// The wait is also bounded by the StopTimeout.
+func WithDrainPeriod(period time.Duration) Option {
+	return func(s *options) {
+		s.drainPeriod = period
+	}
+}
+
+// WithReloadSignal sets the signals which trigger reloading the application configuration. Default is syscall.SIGHUP,
+// call it without signals to disable reloading. The components subscribed by conf.Configuration.Subscribe
+// will be notified after reloading.
+func WithReloadSignal(sigs ...os.Signal) Option {
+	return func(s *options) {
+		s.reloadCh = sigs
+	}
+}
+
+// WithBeforeStart adds hooks called before starting servers. An error returned by the hook aborts App.Run.
+func WithBeforeStart(hooks ...func(ctx context.Context) error) Option {
+	return func(s *options) {
+		s.beforeStart = appendHooks(s.beforeStart, hooks)
+	}
+}
+
+// WithAfterStart adds hooks called after all servers started and ready. An error returned by the hook stops the App.
+func WithAfterStart(hooks ...func(ctx context.Context) error) Option {
+	return func(s *options) {
+		s.afterStart = appendHooks(s.afterStart, hooks)
+	}
+}
+
+// WithBeforeStop adds hooks called before stopping servers with the StopTimeout context.
+func WithBeforeStop(hooks ...func(ctx context.Context) error) Option {
+	return func(s *options) {
+		s.beforeStop = appendHooks(s.beforeStop, hooks)
+	}
+}
+
+// WithAfterStop adds hooks called after all servers stopped with the StopTimeout context.
+func WithAfterStop(hooks ...func(ctx context.Context) error) Option {
+	return func(s *options) {
+		s.afterStop = appendHooks(s.afterStop, hooks)
+	}
+}
+
+func appendHooks(dst []Hook, hooks []func(ctx context.Context) error) []Hook {
+	for _, hook := range hooks {
+		dst = append(dst, hook)
+	}
+	return dst
+}
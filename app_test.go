@@ -4,10 +4,17 @@ import (
 	"context"
 	"errors"
 	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	wclog "github.com/tsingsun/woocoo/pkg/log"
 	"github.com/tsingsun/woocoo/rpc/grpcx"
 	"github.com/tsingsun/woocoo/rpc/grpcx/registry"
 	"github.com/tsingsun/woocoo/test/wctest"
@@ -190,3 +197,272 @@ func TestSync(t *testing.T) {
 	err := app.Sync()
 	assert.NoError(t, err)
 }
+
+func TestApp_CloseLogger(t *testing.T) {
+	t.Cleanup(func() {
+		wclog.InitGlobalLogger()
+	})
+	file := filepath.Join(t.TempDir(), "app.log")
+	cnf := conf.NewFromStringMap(map[string]any{
+		"log": map[string]any{
+			"disableSampling": true,
+			"cores": []any{
+				map[string]any{"level": "info", "outputPaths": []any{file},
+					"async": map[string]any{"flushInterval": "1h"}},
+			},
+		},
+	})
+	app := New(WithAppConfiguration(cnf))
+	app.RegisterServer(&readyServer{name: "web", events: &[]string{}, mu: &sync.Mutex{}, stopCh: make(chan struct{})})
+	require.NotNil(t, app.logger)
+	wclog.Info("running")
+	time.AfterFunc(time.Millisecond*100, func() {
+		app.Stop()
+	})
+	require.NoError(t, app.Run())
+	assert.NoError(t, app.logger.Close(), "close again")
+	bs, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(bs), "running")
+	wclog.Info("stopped")
+	bs, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(bs), "stopped", "should write directly after closed")
+}
+
+type readyServer struct {
+	name    string
+	delay   time.Duration
+	never   bool
+	events  *[]string
+	mu      *sync.Mutex
+	readyAt time.Time
+	stopCh  chan struct{}
+}
+
+func (s *readyServer) record(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*s.events = append(*s.events, event+":"+s.name)
+}
+
+func (s *readyServer) Start(ctx context.Context) error {
+	s.mu.Lock()
+	s.readyAt = time.Now().Add(s.delay)
+	s.mu.Unlock()
+	s.record("start")
+	select {
+	case <-ctx.Done():
+	case <-s.stopCh:
+	}
+	return nil
+}
+
+func (s *readyServer) Stop(context.Context) error {
+	s.record("stop")
+	close(s.stopCh)
+	return nil
+}
+
+func (s *readyServer) Drain(context.Context) error {
+	s.record("drain")
+	return nil
+}
+
+func (s *readyServer) Ready(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.never || s.readyAt.IsZero() || time.Now().Before(s.readyAt) {
+		return errors.New("not ready")
+	}
+	return nil
+}
+
+func TestApp_DependsOn(t *testing.T) {
+	newServers := func(names ...string) (map[string]*readyServer, *[]string) {
+		events := &[]string{}
+		mu := &sync.Mutex{}
+		srvs := make(map[string]*readyServer)
+		for _, name := range names {
+			srvs[name] = &readyServer{name: name, events: events, mu: mu, stopCh: make(chan struct{})}
+		}
+		return srvs, events
+	}
+	t.Run("order", func(t *testing.T) {
+		srvs, events := newServers("grpc", "cache", "web")
+		srvs["cache"].delay = time.Millisecond * 200
+		app := &App{opts: options{StopTimeout: time.Second, StartTimeout: time.Second}}
+		app.ctx, app.cancel = context.WithCancel(context.Background())
+		time.AfterFunc(time.Millisecond*500, func() {
+			app.Stop()
+		})
+		app.RegisterServer(srvs["grpc"], srvs["cache"], srvs["web"])
+		app.DependsOn(srvs["web"], srvs["grpc"])
+		app.DependsOn(srvs["grpc"], srvs["cache"])
+		require.NoError(t, app.Run())
+		assert.Equal(t, []string{"start:cache", "start:grpc", "start:web",
+			"drain:web", "drain:grpc", "drain:cache", "stop:web", "stop:grpc", "stop:cache"}, *events)
+	})
+	t.Run("not ready", func(t *testing.T) {
+		srvs, events := newServers("grpc", "cache")
+		srvs["cache"].never = true
+		app := &App{opts: options{StopTimeout: time.Second, StartTimeout: time.Millisecond * 200}}
+		app.ctx, app.cancel = context.WithCancel(context.Background())
+		app.RegisterServer(srvs["grpc"], srvs["cache"])
+		app.DependsOn(srvs["grpc"], srvs["cache"])
+		err := app.Run()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, []string{"start:cache", "drain:cache", "stop:cache"}, *events)
+	})
+	t.Run("stop while starting", func(t *testing.T) {
+		srvs, events := newServers("a", "b", "c")
+		app := &App{opts: options{StopTimeout: time.Second, StartTimeout: time.Second, interval: time.Minute,
+			beforeStop: []Hook{func(context.Context) error {
+				srvs["a"].record("beforeStop")
+				return nil
+			}},
+		}}
+		app.ctx, app.cancel = context.WithCancel(context.Background())
+		time.AfterFunc(time.Millisecond*100, func() {
+			app.Stop()
+		})
+		app.RegisterServer(srvs["a"], srvs["b"], srvs["c"])
+		begin := time.Now()
+		require.NoError(t, app.Run())
+		assert.Less(t, time.Since(begin), time.Second)
+		assert.Equal(t, []string{"start:a", "beforeStop:a", "drain:a", "stop:a"}, *events)
+	})
+	t.Run("cyclic", func(t *testing.T) {
+		srvs, _ := newServers("a", "b")
+		app := &App{}
+		app.ctx, app.cancel = context.WithCancel(context.Background())
+		app.RegisterServer(srvs["a"], srvs["b"])
+		app.DependsOn(srvs["a"], srvs["b"])
+		app.DependsOn(srvs["b"], srvs["a"])
+		assert.ErrorContains(t, app.Run(), "cyclic dependency")
+	})
+	t.Run("unregistered", func(t *testing.T) {
+		srvs, _ := newServers("a", "b")
+		app := &App{}
+		app.ctx, app.cancel = context.WithCancel(context.Background())
+		app.RegisterServer(srvs["a"])
+		app.DependsOn(srvs["a"], srvs["b"])
+		assert.ErrorContains(t, app.Run(), "is not registered")
+	})
+}
+
+func TestApp_Hooks(t *testing.T) {
+	// the hooks are called in the different goroutines.
+	var mu sync.Mutex
+	newHook := func(events *[]string, name string, err error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			*events = append(*events, name)
+			return err
+		}
+	}
+	t.Run("order", func(t *testing.T) {
+		var events []string
+		app := New(WithAppConfiguration(conf.New()),
+			WithBeforeStart(newHook(&events, "beforeStart1", nil), newHook(&events, "beforeStart2", nil)),
+			WithAfterStart(newHook(&events, "afterStart", nil)),
+			WithBeforeStop(newHook(&events, "beforeStop", errors.New("beforeStop"))),
+			WithAfterStop(func(ctx context.Context) error {
+				_, ok := ctx.Deadline()
+				assert.True(t, ok)
+				return newHook(&events, "afterStop", nil)(ctx)
+			}),
+		)
+		app.RegisterServer(&server1{})
+		time.AfterFunc(time.Millisecond*200, func() {
+			app.Stop()
+		})
+		assert.ErrorContains(t, app.Run(), "beforeStop")
+		assert.Equal(t, []string{"beforeStart1", "beforeStart2", "afterStart", "beforeStop", "afterStop"}, events)
+	})
+	t.Run("before start error", func(t *testing.T) {
+		var events []string
+		app := New(WithAppConfiguration(conf.New()),
+			WithBeforeStart(newHook(&events, "beforeStart1", errors.New("abort")), newHook(&events, "beforeStart2", nil)),
+			WithBeforeStop(newHook(&events, "beforeStop", nil)),
+		)
+		app.RegisterServer(&server1{})
+		assert.ErrorContains(t, app.Run(), "abort")
+		assert.Equal(t, []string{"beforeStart1"}, events)
+	})
+	t.Run("after start error", func(t *testing.T) {
+		var events []string
+		app := New(WithAppConfiguration(conf.New()),
+			WithAfterStart(newHook(&events, "afterStart", errors.New("abort"))),
+			WithAfterStop(newHook(&events, "afterStop", nil)),
+		)
+		app.RegisterServer(&server1{})
+		assert.ErrorContains(t, app.Run(), "abort")
+		assert.Equal(t, []string{"afterStart", "afterStop"}, events)
+	})
+}
+
+func TestApp_DrainPeriod(t *testing.T) {
+	events := &[]string{}
+	srv := &readyServer{name: "web", events: events, mu: &sync.Mutex{}, stopCh: make(chan struct{})}
+	t.Run("period", func(t *testing.T) {
+		app := New(WithAppConfiguration(conf.New()), WithDrainPeriod(time.Millisecond*300))
+		app.RegisterServer(srv)
+		var stopping time.Time
+		time.AfterFunc(time.Millisecond*100, func() {
+			stopping = time.Now()
+			app.Stop()
+		})
+		require.NoError(t, app.Run())
+		assert.GreaterOrEqual(t, time.Since(stopping), time.Millisecond*300)
+		assert.Equal(t, []string{"start:web", "drain:web", "stop:web"}, *events)
+	})
+	t.Run("bounded by stop timeout", func(t *testing.T) {
+		srv.stopCh = make(chan struct{})
+		app := New(WithAppConfiguration(conf.New()), WithDrainPeriod(time.Minute))
+		app.opts.StopTimeout = time.Millisecond * 200
+		app.RegisterServer(srv)
+		time.AfterFunc(time.Millisecond*100, func() {
+			app.Stop()
+		})
+		begin := time.Now()
+		require.NoError(t, app.Run())
+		assert.Less(t, time.Since(begin), time.Second)
+	})
+}
+
+func TestApp_ReloadSignal(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("appName: test\ncache:\n  ttl: 1m\n"), 0600))
+	cnf := conf.New(conf.WithBaseDir(dir), conf.WithLocalPath(file)).Load()
+	reloaded := make(chan string, 1)
+	cnf.Subscribe("cache", func(_, new *conf.Configuration) {
+		reloaded <- new.String("ttl")
+	})
+	app := New(WithAppConfiguration(cnf), WithReloadSignal(syscall.SIGHUP),
+		WithAfterStart(func(ctx context.Context) error {
+			if err := os.WriteFile(file, []byte("appName: test\ncache:\n  ttl: 2m\n"), 0600); err != nil {
+				return err
+			}
+			p, err := os.FindProcess(os.Getpid())
+			if err != nil {
+				return err
+			}
+			return p.Signal(syscall.SIGHUP)
+		}),
+	)
+	app.RegisterServer(&readyServer{name: "web", events: &[]string{}, mu: &sync.Mutex{}, stopCh: make(chan struct{})})
+	go func() {
+		select {
+		case ttl := <-reloaded:
+			assert.Equal(t, "2m", ttl)
+		case <-time.After(time.Second * 2):
+			assert.Fail(t, "configuration is not reloaded")
+		}
+		app.Stop()
+	}()
+	require.NoError(t, app.Run())
+	assert.Equal(t, "2m", cnf.String("cache.ttl"))
+}
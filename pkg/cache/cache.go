@@ -25,6 +25,7 @@ var (
 	ErrDriverNameMiss      = errors.New("cache: driverName is empty")
 	ErrCacheMiss           = errors.New("cache: key is missing")
 	ErrReceiverMustPointer = errors.New("cache: value receiver must be a pointer")
+	ErrTagsUnsupported     = errors.New("cache: tags are not supported by the driver")
 )
 
 // Cache is the interface for cache.
@@ -41,6 +42,13 @@ type Cache interface {
 	IsNotFound(err error) bool
 }
 
+// TaggedCache is an optional interface of Cache, which deletes the values set by WithTags together.
+type TaggedCache interface {
+	Cache
+	// InvalidateTags deletes the values of the given tags.
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
+
 // SkipMode controls the cache load which level from a combined cache .
 type SkipMode int
 
@@ -203,6 +203,7 @@ func TestCacheMethod(t *testing.T) {
 		})
 		err := fmt.Errorf("err %w", ErrCacheMiss)
 		assert.True(t, IsNotFound(err))
+		assert.ErrorIs(t, InvalidateTags(context.Background(), "tag"), ErrTagsUnsupported)
 	})
 }
 
@@ -269,6 +270,13 @@ func TestOptions(t *testing.T) {
 				assert.Equal(t, SkipRemote, opts.Skip)
 			},
 		},
+		{
+			name:    "WithTags",
+			options: []Option{WithTags("a", "b"), WithTags("c")},
+			do: func(opts *Options) {
+				assert.Equal(t, []string{"a", "b", "c"}, opts.Tags)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
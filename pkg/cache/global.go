@@ -74,3 +74,13 @@ func Del(ctx context.Context, key string) error {
 func IsNotFound(err error) bool {
 	return _defaultDriver.IsNotFound(err)
 }
+
+// InvalidateTags deletes the values of the given tags by the default driver, it returns ErrTagsUnsupported
+// if the driver is not a TaggedCache.
+func InvalidateTags(ctx context.Context, tags ...string) error {
+	tc, ok := _defaultDriver.(TaggedCache)
+	if !ok {
+		return ErrTagsUnsupported
+	}
+	return tc.InvalidateTags(ctx, tags...)
+}
@@ -23,17 +23,17 @@ var (
 	ErrValueReceiverNil = errors.New("cache: value receiver must not nil pointer")
 )
 
-var _ cache.Cache = (*TinyLFU)(nil)
+var _ cache.TaggedCache = (*TinyLFU)(nil)
 
 // Config is the configuration for TinyLFU cache
 type Config struct {
 	// DriverName set it to register to cache manager.
 	DriverName string `yaml:"driverName" json:"driverName"`
-	Size       int    `yaml:"size" json:"size"`
-	Samples    int    `yaml:"samples" json:"samples"`
+	Size       int    `yaml:"size" json:"size" validate:"min=1"`
+	Samples    int    `yaml:"samples" json:"samples" validate:"min=1" default:"100000"`
 	// TTL is default to set item ttl, if you use no expired cache, this value is not used.
-	TTL       time.Duration `yaml:"ttl" json:"ttl"`
-	Deviation int64         `yaml:"deviation" json:"deviation"`
+	TTL       time.Duration `yaml:"ttl" json:"ttl" validate:"min=0" default:"1m"`
+	Deviation int64         `yaml:"deviation" json:"deviation" validate:"min=1" default:"10"`
 	// Subsidiary indicate whether the cache is a subsidiary cache,
 	// if true, the cache will not be registered to cache manager and ttl will be the max ttl.
 	Subsidiary bool `yaml:"subsidiary" json:"subsidiary"`
@@ -43,15 +43,31 @@ type Config struct {
 //
 // Default ttl is 1 minute.Notice that the ttl will be less the setting,
 // randomly reduced by a value between 0 and the offset.
+//
+// The tags set by cache.WithTags are kept in memory, setting a key again replaces its tags. The expired and evicted
+// keys are pruned from their tags when the tagged keys are twice the size of the cache, the evicted keys are found
+// by getting them from the cache, which counts as an access in the frequency sketch.
 type TinyLFU struct {
 	Config
 	mu     sync.Mutex
 	rand   *rand.Rand
 	lfu    *tinylfu.T
 	offset time.Duration
+	// tags is the keys of the tags.
+	tags map[string]map[string]struct{}
+	// keyTags is the tags of the keys.
+	keyTags map[string]*taggedKey
 
 	marshal   cache.MarshalFunc
 	unmarshal cache.UnmarshalFunc
+	// unsubscribe cancels the subscription of the configuration, nil if not subscribed.
+	unsubscribe func()
+}
+
+// taggedKey is the tags of a key and the expiration of its value.
+type taggedKey struct {
+	tags     []string
+	expireAt time.Time
 }
 
 // Register cache to cache manager
@@ -64,21 +80,46 @@ func (c *TinyLFU) Apply(cnf *conf.Configuration) error {
 	if err := cnf.Unmarshal(&c.Config); err != nil {
 		return err
 	}
-	if c.Subsidiary {
-		c.offset = c.TTL / time.Duration(c.Deviation)
-		if c.offset > maxOffset {
-			c.offset = maxOffset
-		}
-	}
+	c.resetOffset()
 	if c.DriverName != "" {
 		if err := c.Register(); err != nil {
 			return err
 		}
 	}
 	c.lfu = tinylfu.New(c.Size, c.Samples)
+	c.tags = make(map[string]map[string]struct{})
+	c.keyTags = make(map[string]*taggedKey)
 	return nil
 }
 
+func (c *TinyLFU) resetOffset() {
+	if c.Subsidiary {
+		c.offset = c.TTL / time.Duration(c.Deviation)
+		if c.offset > maxOffset {
+			c.offset = maxOffset
+		}
+	}
+}
+
+// reload applies the changed ttl and deviation at runtime, the size of cache takes effect after restarting.
+func (c *TinyLFU) reload(_, cnf *conf.Configuration) {
+	if cnf == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cfg := c.Config
+	if err := cnf.Unmarshal(&cfg); err != nil {
+		return
+	}
+	c.TTL = cfg.TTL
+	c.Deviation = cfg.Deviation
+	c.resetOffset()
+}
+
+// NewTinyLFU creates a TinyLFU cache by configuration. If the configuration is a sub node of an application
+// configuration, the changed ttl of its path takes effect at runtime, call Close to stop observing it when the cache
+// is no longer used.
 func NewTinyLFU(cnf *conf.Configuration) (*TinyLFU, error) {
 	c := TinyLFU{
 		rand: rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
@@ -91,6 +132,9 @@ func NewTinyLFU(cnf *conf.Configuration) (*TinyLFU, error) {
 	if err := c.Apply(cnf); err != nil {
 		return nil, err
 	}
+	if path := cnf.Path(); path != "" {
+		c.unsubscribe = cnf.Root().Subscribe(path, c.reload)
+	}
 
 	if c.marshal == nil {
 		c.marshal = cache.DefaultMarshalFunc
@@ -184,7 +228,11 @@ func (c *TinyLFU) setOptions(_ context.Context, key string, value any, ttl time.
 			return fmt.Errorf("setnx key already exist:%s", key)
 		}
 	}
-	return c.setValue(key, value, ttl, opt.Raw)
+	if err := c.setValue(key, value, ttl, opt.Raw); err != nil {
+		return err
+	}
+	c.tag(key, ttl, opt.Tags)
+	return nil
 }
 
 // skip remote cache is mean that only set local cache as not a subsidiary cache temporarily,
@@ -231,7 +279,69 @@ func (c *TinyLFU) SetInner(_ context.Context, key string, value any, ttl time.Du
 	defer c.mu.Unlock()
 
 	ttl = c.fixTTL(ttl, opt)
-	return c.setValue(key, value, ttl, opt.Raw)
+	if err := c.setValue(key, value, ttl, opt.Raw); err != nil {
+		return err
+	}
+	c.tag(key, ttl, opt.Tags)
+	return nil
+}
+
+// tag replaces the tags of the key and refreshes the expiration of a tagged key, the caller must hold the lock.
+// Overwriting a key without tags removes it from its previous tags.
+func (c *TinyLFU) tag(key string, ttl time.Duration, tags []string) {
+	c.untag(key)
+	if len(tags) == 0 {
+		return
+	}
+	tk := &taggedKey{}
+	if ttl != 0 {
+		tk.expireAt = time.Now().Add(ttl)
+	}
+	c.keyTags[key] = tk
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		if _, ok := keys[key]; ok {
+			continue
+		}
+		keys[key] = struct{}{}
+		tk.tags = append(tk.tags, tag)
+	}
+	if len(c.keyTags) > 2*c.Size {
+		c.pruneTags()
+	}
+}
+
+// pruneTags removes the expired and evicted keys from their tags, the caller must hold the lock.
+func (c *TinyLFU) pruneTags() {
+	now := time.Now()
+	for key, tk := range c.keyTags {
+		if !tk.expireAt.IsZero() && now.After(tk.expireAt) {
+			c.untag(key)
+			continue
+		}
+		if _, ok := c.lfu.Get(key); !ok {
+			c.untag(key)
+		}
+	}
+}
+
+// untag removes the key from its tags, the caller must hold the lock.
+func (c *TinyLFU) untag(key string) {
+	tk, ok := c.keyTags[key]
+	if !ok {
+		return
+	}
+	for _, tag := range tk.tags {
+		delete(c.tags[tag], key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+	delete(c.keyTags, key)
 }
 
 func (c *TinyLFU) Has(_ context.Context, key string) bool {
@@ -246,6 +356,21 @@ func (c *TinyLFU) Del(_ context.Context, key string) error {
 	defer c.mu.Unlock()
 
 	c.lfu.Del(key)
+	c.untag(key)
+	return nil
+}
+
+// InvalidateTags deletes the values of the given tags.
+func (c *TinyLFU) InvalidateTags(_ context.Context, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			c.lfu.Del(key)
+			c.untag(key)
+		}
+	}
 	return nil
 }
 
@@ -253,6 +378,22 @@ func (c *TinyLFU) IsNotFound(err error) bool {
 	return errors.Is(err, cache.ErrCacheMiss)
 }
 
+// Close stops observing the changes of the configuration, the cache is still usable.
+func (c *TinyLFU) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.unsubscribe != nil {
+		c.unsubscribe()
+		c.unsubscribe = nil
+	}
+	return nil
+}
+
 func (c *TinyLFU) Clean() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.lfu = tinylfu.New(c.Size, c.Samples)
+	c.tags = make(map[string]map[string]struct{})
+	c.keyTags = make(map[string]*taggedKey)
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -47,6 +49,59 @@ ttl: "string"
 		_, err := NewTinyLFU(cnf)
 		assert.ErrorContains(t, err, `'ttl' time: invalid duration`)
 	})
+	t.Run("strict", func(t *testing.T) {
+		cnf := conf.NewFromBytes([]byte(`
+size: 0
+sample: 100
+`), conf.WithStrict())
+		_, err := NewTinyLFU(cnf)
+		assert.EqualError(t, err, "conf: unknown keys: sample")
+		cnf = conf.NewFromBytes([]byte(`
+size: 0
+`), conf.WithStrict())
+		_, err = NewTinyLFU(cnf)
+		assert.EqualError(t, err, "conf: size: failed on the 'min=1' validation, got 0")
+	})
+	t.Run("reload", func(t *testing.T) {
+		cnf := conf.NewFromBytes([]byte(`
+size: 100
+ttl: 10m
+deviation: 10
+subsidiary: true
+`))
+		c, err := NewTinyLFU(cnf)
+		require.NoError(t, err)
+		c.reload(nil, conf.NewFromBytes([]byte(`
+ttl: 1m
+deviation: 20
+`)))
+		assert.Equal(t, time.Minute, c.TTL)
+		assert.Equal(t, int64(20), c.Deviation)
+		assert.Equal(t, 3*time.Second, c.offset)
+	})
+	t.Run("subscribe", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "app.yaml")
+		write := func(ttl string) {
+			require.NoError(t, os.WriteFile(file, []byte("cache:\n  size: 100\n  ttl: "+ttl+"\n"), 0600))
+		}
+		write("10m")
+		cnf := conf.New(conf.WithBaseDir(dir), conf.WithLocalPath(file)).Load()
+		c, err := NewTinyLFU(cnf.Sub("cache"))
+		require.NoError(t, err)
+		write("1m")
+		require.NoError(t, cnf.TryReload())
+		assert.Equal(t, time.Minute, c.TTL)
+
+		require.NoError(t, c.Close())
+		write("2m")
+		require.NoError(t, cnf.TryReload())
+		assert.Equal(t, time.Minute, c.TTL, "should not reload after closed")
+
+		root, err := NewTinyLFU(conf.NewFromStringMap(map[string]any{"size": 100}))
+		require.NoError(t, err)
+		assert.Nil(t, root.unsubscribe, "should not subscribe the whole tree")
+	})
 }
 
 func TestTinyLFU_Get_CorruptionOnExpiry(t *testing.T) {
@@ -325,3 +380,73 @@ func TestTinyLFU_Set(t *testing.T) {
 		assert.NoError(t, local.Set(ctx, "key", "value", cache.WithSetXX()))
 	})
 }
+
+func TestTinyLFU_InvalidateTags(t *testing.T) {
+	local, err := NewTinyLFU(conf.NewFromStringMap(map[string]any{
+		"size":    "100",
+		"samples": "100",
+	}))
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, local.Set(ctx, "p1:detail", "v", cache.WithTags("product:1")))
+	require.NoError(t, local.Set(ctx, "p1:price", "v", cache.WithTags("product:1", "price")))
+	require.NoError(t, local.Set(ctx, "p2:price", "v", cache.WithTags("product:2"), cache.WithTags("price")))
+	require.NoError(t, local.SetInner(ctx, "p2:detail", "v", 0, &cache.Options{Tags: []string{"product:2"}}))
+	require.NoError(t, local.Set(ctx, "other", "v"))
+
+	require.NoError(t, local.InvalidateTags(ctx, "product:1"))
+	assert.False(t, local.Has(ctx, "p1:detail"))
+	assert.False(t, local.Has(ctx, "p1:price"))
+	assert.True(t, local.Has(ctx, "p2:price"))
+	assert.Equal(t, map[string]map[string]struct{}{
+		"price":     {"p2:price": {}},
+		"product:2": {"p2:price": {}, "p2:detail": {}},
+	}, local.tags)
+
+	require.NoError(t, local.Del(ctx, "p2:price"))
+	assert.Equal(t, []string{"product:2"}, local.keyTags["p2:detail"].tags)
+	assert.NotContains(t, local.tags, "price")
+
+	require.NoError(t, local.InvalidateTags(ctx, "product:2", "miss"))
+	assert.False(t, local.Has(ctx, "p2:detail"))
+	assert.True(t, local.Has(ctx, "other"))
+	assert.Empty(t, local.tags)
+	assert.Empty(t, local.keyTags)
+
+	t.Run("overwrite", func(t *testing.T) {
+		require.NoError(t, local.Set(ctx, "key", "v", cache.WithTags("t1", "t2")))
+		require.NoError(t, local.Set(ctx, "key", "v", cache.WithTags("t2", "t3")))
+		assert.Equal(t, []string{"t2", "t3"}, local.keyTags["key"].tags)
+		assert.NotContains(t, local.tags, "t1")
+
+		require.NoError(t, local.Set(ctx, "key", "v"))
+		assert.Empty(t, local.tags)
+		assert.Empty(t, local.keyTags)
+		require.NoError(t, local.InvalidateTags(ctx, "t1", "t2", "t3"))
+		assert.True(t, local.Has(ctx, "key"), "should not be invalidated by the old tags")
+	})
+	t.Run("clean", func(t *testing.T) {
+		require.NoError(t, local.Set(ctx, "key", "v", cache.WithTags("tag")))
+		local.Clean()
+		assert.Empty(t, local.tags)
+		assert.Empty(t, local.keyTags)
+	})
+	t.Run("prune", func(t *testing.T) {
+		local, err := NewTinyLFU(conf.NewFromStringMap(map[string]any{
+			"size":    "10",
+			"samples": "100",
+		}))
+		require.NoError(t, err)
+		require.NoError(t, local.Set(ctx, "expired", "v", cache.WithTTL(time.Millisecond), cache.WithTags("tag")))
+		time.Sleep(time.Millisecond * 2)
+		for i := 0; i < 100; i++ {
+			require.NoError(t, local.Set(ctx, fmt.Sprintf("key%d", i), "v", cache.WithTags("tag", fmt.Sprintf("tag%d", i))))
+		}
+		assert.LessOrEqual(t, len(local.keyTags), 2*local.Size)
+		assert.LessOrEqual(t, len(local.tags), 2*local.Size+1)
+		assert.NotContains(t, local.keyTags, "expired")
+		for key := range local.tags["tag"] {
+			assert.Contains(t, local.keyTags, key)
+		}
+	})
+}
@@ -26,6 +26,9 @@ type Options struct {
 	Raw bool
 	// Group indicates whether to singleflight.
 	Group bool
+	// Tags are the tags of the value to set, the values can be invalidated together by the tag.
+	// It is supported by the TaggedCache.
+	Tags []string
 }
 
 func ApplyOptions(opts ...Option) *Options {
@@ -91,3 +94,10 @@ func WithGroup() Option {
 		o.Group = true
 	}
 }
+
+// WithTags sets the tags of the value to set, see TaggedCache. Setting a key again replaces its tags.
+func WithTags(tags ...string) Option {
+	return func(o *Options) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
@@ -0,0 +1,119 @@
+package redisc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultInvalidationChannel = "woocoo:cache:invalidate"
+
+type (
+	// InvalidationConfig is the settings to invalidate the local caches of the instances by redis pub/sub.
+	InvalidationConfig struct {
+		// Channel is the redis channel, default is "woocoo:cache:invalidate:" + appName of the root configuration,
+		// so that the applications sharing a redis do not disturb each other.
+		Channel string `yaml:"channel" json:"channel"`
+	}
+
+	// invalidator publishes the changed keys and deletes the keys changed by other instances from the local cache.
+	invalidator struct {
+		channel string
+		// id identifies the instance to ignore the messages published by itself.
+		id     string
+		pubsub *redis.PubSub
+		done   chan struct{}
+	}
+
+	invalidationMessage struct {
+		Source string   `json:"src"`
+		Keys   []string `json:"keys"`
+	}
+
+	subscriber interface {
+		Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+	}
+)
+
+func newInvalidator(channel string) (*invalidator, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &invalidator{
+		channel: channel,
+		id:      hex.EncodeToString(id),
+	}, nil
+}
+
+// subscribe subscribes the channel if the local cache is enabled, the local cache is flushed after resubscribing
+// because the messages may be missed while reconnecting.
+func (cd *Redisc) subscribe(ctx context.Context) error {
+	if cd.local == nil {
+		return nil
+	}
+	sc, ok := cd.redis.(subscriber)
+	if !ok {
+		return errors.New("redisc: the redis client does not support pub/sub for invalidation")
+	}
+	pubsub := sc.Subscribe(ctx, cd.invalidator.channel)
+	// wait for the confirmation, the following confirmations are of resubscribing.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close() //nolint:errcheck
+		return err
+	}
+	cd.invalidator.pubsub = pubsub
+	cd.invalidator.done = make(chan struct{})
+	go cd.receive(pubsub.ChannelWithSubscriptions())
+	return nil
+}
+
+func (cd *Redisc) receive(ch <-chan any) {
+	defer close(cd.invalidator.done)
+	for msg := range ch {
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if msg.Kind == "subscribe" {
+				cd.CleanLocalCache()
+			}
+		case *redis.Message:
+			var im invalidationMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &im); err != nil || im.Source == cd.invalidator.id {
+				continue
+			}
+			for _, key := range im.Keys {
+				cd.DeleteFromLocalCache(key)
+			}
+		}
+	}
+}
+
+// publish notifies other instances to delete the keys from their local caches.
+func (cd *Redisc) publish(ctx context.Context, keys ...string) error {
+	if cd.invalidator == nil || len(keys) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(invalidationMessage{Source: cd.invalidator.id, Keys: keys})
+	if err != nil {
+		return err
+	}
+	return cd.redis.Publish(ctx, cd.invalidator.channel, payload).Err()
+}
+
+// Close stops receiving the invalidation messages and closes the local cache, the redis client is not closed.
+func (cd *Redisc) Close() error {
+	var err error
+	if cd.local != nil {
+		err = cd.local.Close()
+	}
+	if cd.invalidator == nil || cd.invalidator.pubsub == nil {
+		return err
+	}
+	err = errors.Join(err, cd.invalidator.pubsub.Close())
+	<-cd.invalidator.done
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/tsingsun/woocoo/pkg/cache"
@@ -13,13 +14,40 @@ import (
 	"golang.org/x/sync/singleflight"
 )
 
-var _ cache.Cache = (*Redisc)(nil)
+var _ cache.TaggedCache = (*Redisc)(nil)
+
+const (
+	defaultTagPrefix = "cache:tag:"
+	// keyTagsPrefix is the prefix after the TagPrefix of the sets holding the tags of a key.
+	keyTagsPrefix = "key:"
+)
+
+// tagScript adds the member to the set and keeps the set alive as long as its longest-lived member.
+// It is used for both the tag sets and the sets of the tags of a key.
+// ARGV[1] is the member, ARGV[2] is the ttl in milliseconds, 0 means no expiration.
+var tagScript = redis.NewScript(`
+local ttl = redis.call('PTTL', KEYS[1])
+redis.call('SADD', KEYS[1], ARGV[1])
+local exp = tonumber(ARGV[2])
+if exp == 0 then
+	redis.call('PERSIST', KEYS[1])
+elseif ttl == -2 or (ttl >= 0 and ttl < exp) then
+	redis.call('PEXPIRE', KEYS[1], exp)
+end
+return 1
+`)
 
 type (
 	Config struct {
 		// DriverName set it to register to cache manager.
-		DriverName string `yaml:"driverName" json:"driverName"`
+		DriverName string `yaml:"driverName" json:"driverName" validate:"omitempty,printascii,excludesall= "`
 		UseStats   bool   `yaml:"stats" json:"stats"`
+		// TagPrefix is the key prefix of the tag sets in redis, default is "cache:tag:". The tags of a key are kept
+		// in the set of TagPrefix + "key:" + key, so the tags should not start with "key:".
+		TagPrefix string `yaml:"tagPrefix" json:"tagPrefix"`
+		// Invalidation enables the invalidation of the local caches across the instances, the changed keys are
+		// published by Set and Del and deleted from the local caches of other instances.
+		Invalidation *InvalidationConfig `yaml:"invalidation" json:"invalidation"`
 	}
 	// Redisc is a cache implementation of redis.
 	//
@@ -33,7 +61,8 @@ type (
 		marshal   cache.MarshalFunc
 		unmarshal cache.UnmarshalFunc
 
-		group singleflight.Group
+		group       singleflight.Group
+		invalidator *invalidator
 	}
 
 	Option func(*Redisc)
@@ -59,6 +88,9 @@ func WithRedisClient(cli redis.UniversalClient) Option {
 //		  size: 1000 # optional, default is 1000
 //		  samples: 100000 # optional, default is 100000
 //		  ttl: 1m # optional, default is 1m
+//		tagPrefix: "cache:tag:" # optional, the key prefix of the tag sets
+//		invalidation: # optional, invalidate the local caches of other instances by pub/sub
+//		  channel: "woocoo:cache:invalidate:{appName}" # optional
 //
 // If you want to register to cache manager, set a `driverName` in configuration.
 func New(cfg *conf.Configuration, opts ...Option) (*Redisc, error) {
@@ -85,12 +117,21 @@ func (cd *Redisc) Register() error {
 
 // Apply conf.configurable
 func (cd *Redisc) Apply(cnf *conf.Configuration) (err error) {
-	if err := cnf.Unmarshal(&cd.Config); err != nil {
+	// the configuration is shared with the redis client, so it is not decoded exactly even in strict mode.
+	if err := cnf.Parser().Unmarshal("", &cd.Config); err != nil {
 		return err
 	}
+	if cnf.IsStrict() {
+		if err := cnf.Validate(&cd.Config); err != nil {
+			return err
+		}
+	}
 	if cd.UseStats {
 		cd.stats = &cache.Stats{}
 	}
+	if cd.TagPrefix == "" {
+		cd.TagPrefix = defaultTagPrefix
+	}
 	if cnf.IsSet("local") {
 		lcfg := cnf.Sub("local")
 		lcfg.Parser().Set("subsidiary", true)
@@ -106,6 +147,11 @@ func (cd *Redisc) Apply(cnf *conf.Configuration) (err error) {
 		}
 		cd.redis = remote
 	}
+	if cnf.IsSet("invalidation") {
+		if err = cd.applyInvalidation(cnf); err != nil {
+			return err
+		}
+	}
 	if cd.DriverName != "" {
 		if err = cd.Register(); err != nil {
 			return err
@@ -114,6 +160,23 @@ func (cd *Redisc) Apply(cnf *conf.Configuration) (err error) {
 	return
 }
 
+func (cd *Redisc) applyInvalidation(cnf *conf.Configuration) (err error) {
+	if cd.Invalidation == nil {
+		cd.Invalidation = &InvalidationConfig{}
+	}
+	channel := cd.Invalidation.Channel
+	if channel == "" {
+		channel = defaultInvalidationChannel
+		if app := cnf.Root().AppName(); app != "" {
+			channel += ":" + app
+		}
+	}
+	if cd.invalidator, err = newInvalidator(channel); err != nil {
+		return err
+	}
+	return cd.subscribe(context.Background())
+}
+
 // Get returns the value associated with the given key.
 func (cd *Redisc) Get(ctx context.Context, key string, v any, opts ...cache.Option) error {
 	opt := cache.ApplyOptions(opts...)
@@ -226,10 +289,18 @@ func (cd *Redisc) getSetItemGroup(ctx context.Context, key string, value any, op
 
 // Set sets the value associated with the given key.
 // if ttl < 0 ,will not save to redis,but save to local cache if enabled
+//
+// The other instances are notified to delete the key from their local caches, the values filled by the Getter of Get
+// are not notified because they are the same as the remote ones.
 func (cd *Redisc) Set(ctx context.Context, key string, v any, opts ...cache.Option) error {
 	opt := cache.ApplyOptions(opts...)
-	_, _, err := cd.set(ctx, key, v, opt)
-	return err
+	if _, _, err := cd.set(ctx, key, v, opt); err != nil {
+		return err
+	}
+	if opt.Skip.Is(cache.SkipRemote) {
+		return nil
+	}
+	return cd.publish(ctx, key)
 }
 
 // Set sets the value associated with the given key.
@@ -240,20 +311,30 @@ func (cd *Redisc) set(ctx context.Context, key string, v any, opt *cache.Options
 		if marshaled, err = cd.marshal(v); err != nil {
 			return
 		}
-		var ok bool
-		switch {
-		case opt.SetXX:
-			ok, err = cd.redis.SetXX(ctx, key, marshaled, ttl).Result()
-			if !ok && err == nil {
-				err = fmt.Errorf("setxx: key not exist:%s", key)
-			}
-		case opt.SetNX:
-			ok, err = cd.redis.SetNX(ctx, key, marshaled, ttl).Result()
-			if !ok && err == nil {
-				err = fmt.Errorf("setnx key already exist:%s", key)
+		// the previous tags of the key are read in the same round trip, overwriting the key replaces its tags.
+		var cmds []redis.Cmder
+		cmds, err = cd.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			switch {
+			case opt.SetXX:
+				pipe.SetXX(ctx, key, marshaled, ttl)
+			case opt.SetNX:
+				pipe.SetNX(ctx, key, marshaled, ttl)
+			default:
+				pipe.Set(ctx, key, marshaled, ttl)
 			}
+			pipe.SMembers(ctx, cd.keyTagsKey(key))
+			return nil
+		})
+		if err != nil {
+			return
+		}
+		switch {
+		case opt.SetXX && !cmds[0].(*redis.BoolCmd).Val():
+			err = fmt.Errorf("setxx: key not exist:%s", key)
+		case opt.SetNX && !cmds[0].(*redis.BoolCmd).Val():
+			err = fmt.Errorf("setnx key already exist:%s", key)
 		default:
-			err = cd.redis.Set(ctx, key, marshaled, ttl).Err()
+			err = cd.tag(ctx, key, ttl, cmds[1].(*redis.StringSliceCmd).Val(), opt.Tags)
 		}
 	} else if !opt.Raw {
 		if marshaled, err = cd.marshal(v); err != nil {
@@ -281,18 +362,135 @@ func (cd *Redisc) Has(ctx context.Context, key string) bool {
 	return cd.redis.Exists(ctx, key).Val() != 0
 }
 
-// Del deletes the given key.
+// Del deletes the given key and removes it from its tags.
 func (cd *Redisc) Del(ctx context.Context, key string) error {
 	cd.DeleteFromLocalCache(key)
-	_, err := cd.redis.Del(ctx, key).Result()
+	if err := cd.del(ctx, []string{key}); err != nil {
+		return err
+	}
+	return cd.publish(ctx, key)
+}
+
+// del deletes the keys from redis and removes them from their tag sets.
+func (cd *Redisc) del(ctx context.Context, keys []string) error {
+	cmds, err := cd.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(ctx, key)
+			pipe.SMembers(ctx, cd.keyTagsKey(key))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// the untagged keys are done in one round trip.
+	tagged := false
+	for i := range keys {
+		if len(cmds[2*i+1].(*redis.StringSliceCmd).Val()) > 0 {
+			tagged = true
+			break
+		}
+	}
+	if !tagged {
+		return nil
+	}
+	_, err = cd.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			tags := cmds[2*i+1].(*redis.StringSliceCmd).Val()
+			if len(tags) == 0 {
+				continue
+			}
+			for _, tag := range tags {
+				pipe.SRem(ctx, cd.tagKey(tag), key)
+			}
+			pipe.Del(ctx, cd.keyTagsKey(key))
+		}
+		return nil
+	})
 	return err
 }
 
+// InvalidateTags deletes the values of the given tags from redis and the local cache.
+//
+// The local caches of other instances are invalidated if Invalidation is enabled, otherwise their values expire by
+// the ttl of the local cache.
+func (cd *Redisc) InvalidateTags(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	if cd.local != nil {
+		cd.local.InvalidateTags(ctx, tags...) //nolint:errcheck
+	}
+	cmds, err := cd.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, tag := range tags {
+			pipe.SMembers(ctx, cd.tagKey(tag))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// remove the read keys only, the keys tagged meanwhile are kept in the tag sets.
+	var deleted []string
+	seen := make(map[string]struct{})
+	for _, cmd := range cmds {
+		for _, key := range cmd.(*redis.StringSliceCmd).Val() {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			cd.DeleteFromLocalCache(key)
+			deleted = append(deleted, key)
+		}
+	}
+	if len(deleted) == 0 {
+		return nil
+	}
+	if err = cd.del(ctx, deleted); err != nil {
+		return err
+	}
+	return cd.publish(ctx, deleted...)
+}
+
+// tag replaces the tags of the key, the key is removed from the previous tags and added to the new tag sets.
+func (cd *Redisc) tag(ctx context.Context, key string, ttl time.Duration, prev, tags []string) error {
+	if len(prev) == 0 && len(tags) == 0 {
+		return nil
+	}
+	_, err := cd.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(prev) > 0 {
+			for _, tag := range prev {
+				pipe.SRem(ctx, cd.tagKey(tag), key)
+			}
+			pipe.Del(ctx, cd.keyTagsKey(key))
+		}
+		for _, tag := range tags {
+			tagScript.Eval(ctx, pipe, []string{cd.tagKey(tag)}, key, ttl.Milliseconds())
+			tagScript.Eval(ctx, pipe, []string{cd.keyTagsKey(key)}, tag, ttl.Milliseconds())
+		}
+		return nil
+	})
+	return err
+}
+
+func (cd *Redisc) tagKey(tag string) string {
+	return cd.TagPrefix + tag
+}
+
+func (cd *Redisc) keyTagsKey(key string) string {
+	return cd.TagPrefix + keyTagsPrefix + key
+}
+
 // IsNotFound returns true if the error is cache.ErrCacheMiss.
 func (cd *Redisc) IsNotFound(err error) bool {
 	return errors.Is(err, cache.ErrCacheMiss)
 }
 
+// Check pings the underlying redis server, it can be used as a health checker.
+func (cd *Redisc) Check(ctx context.Context) error {
+	return cd.redis.Ping(ctx).Err()
+}
+
 // RedisClient returns the underlying redis client.
 func (cd *Redisc) RedisClient() redis.Cmdable {
 	return cd.redis
@@ -3,6 +3,8 @@ package redisc
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -182,6 +184,15 @@ func TestRedisc(t *testing.T) {
 		name string
 		do   func()
 	}{
+		{
+			name: "check",
+			do: func() {
+				rd, rdb := initStandaloneRedisc(t)
+				assert.NoError(t, rd.Check(context.Background()))
+				rdb.Close()
+				assert.Error(t, rd.Check(context.Background()))
+			},
+		},
 		{
 			name: "use default ttl",
 			do: func() {
@@ -616,3 +627,228 @@ func TestCache_CleanLocalCache(t *testing.T) {
 		})
 	}
 }
+
+func TestCache_InvalidateTags(t *testing.T) {
+	ctx := context.Background()
+	t.Run("invalidate", func(t *testing.T) {
+		rc, rdb := initStandaloneRedisc(t)
+		require.NoError(t, rc.Set(ctx, "p1:detail", "v", cache.WithTTL(time.Minute), cache.WithTags("product:1")))
+		require.NoError(t, rc.Set(ctx, "p1:price", "v", cache.WithTTL(time.Hour), cache.WithTags("product:1", "price")))
+		require.NoError(t, rc.Set(ctx, "p2:price", "v", cache.WithTags("price")))
+		require.NoError(t, rc.Set(ctx, "local", "v", cache.WithSkip(cache.SkipRemote), cache.WithTags("product:1")))
+		require.NoError(t, rc.Set(ctx, "other", "v"))
+		members, err := rdb.Members("cache:tag:product:1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"p1:detail", "p1:price"}, members)
+		assert.Equal(t, time.Hour, rdb.TTL("cache:tag:product:1"), "should be the longest ttl")
+
+		require.NoError(t, rc.InvalidateTags(ctx, "product:1"))
+		assert.False(t, rdb.Exists("p1:detail"))
+		assert.False(t, rdb.Exists("p1:price"))
+		assert.False(t, rdb.Exists("cache:tag:product:1"))
+		assert.False(t, rc.Has(ctx, "p1:price"), "should delete from local cache")
+		assert.False(t, rc.Has(ctx, "local"), "should delete the local only value")
+		assert.True(t, rc.Has(ctx, "p2:price"))
+		assert.True(t, rc.Has(ctx, "other"))
+		members, err = rdb.Members("cache:tag:price")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"p2:price"}, members, "the deleted key is removed from other tags")
+		assert.False(t, rdb.Exists("cache:tag:key:p1:price"))
+
+		require.NoError(t, rc.InvalidateTags(ctx))
+		require.NoError(t, rc.InvalidateTags(ctx, "miss"))
+	})
+	t.Run("del", func(t *testing.T) {
+		rc, rdb := initStandaloneRedisc(t)
+		require.NoError(t, rc.Set(ctx, "k1", "v", cache.WithTTL(time.Minute), cache.WithTags("t1", "t2")))
+		require.NoError(t, rc.Set(ctx, "k2", "v", cache.WithTTL(time.Minute), cache.WithTags("t1")))
+		members, err := rdb.Members("cache:tag:key:k1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t1", "t2"}, members)
+		assert.Equal(t, time.Minute, rdb.TTL("cache:tag:key:k1"))
+
+		require.NoError(t, rc.Del(ctx, "k1"))
+		members, err = rdb.Members("cache:tag:t1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"k2"}, members)
+		assert.False(t, rdb.Exists("cache:tag:t2"))
+		assert.False(t, rdb.Exists("cache:tag:key:k1"))
+		// set again without tags is not invalidated by the old tags
+		require.NoError(t, rc.Set(ctx, "k1", "v", cache.WithTTL(time.Minute)))
+		require.NoError(t, rc.InvalidateTags(ctx, "t1", "t2"))
+		assert.True(t, rdb.Exists("k1"))
+		assert.False(t, rdb.Exists("k2"))
+	})
+	t.Run("overwrite", func(t *testing.T) {
+		rc, rdb := initStandaloneRedisc(t)
+		require.NoError(t, rc.Set(ctx, "k1", "v", cache.WithTTL(time.Minute), cache.WithTags("t1", "t2")))
+		require.NoError(t, rc.Set(ctx, "k1", "v", cache.WithTTL(time.Minute), cache.WithTags("t2", "t3")))
+		members, err := rdb.Members("cache:tag:key:k1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t2", "t3"}, members)
+		assert.False(t, rdb.Exists("cache:tag:t1"))
+
+		require.NoError(t, rc.Set(ctx, "k1", "v", cache.WithTTL(time.Minute)))
+		assert.False(t, rdb.Exists("cache:tag:key:k1"))
+		assert.False(t, rdb.Exists("cache:tag:t2"))
+		assert.False(t, rdb.Exists("cache:tag:t3"))
+		require.NoError(t, rc.InvalidateTags(ctx, "t1", "t2", "t3"))
+		assert.True(t, rdb.Exists("k1"), "should not be invalidated by the old tags")
+	})
+	t.Run("del untagged", func(t *testing.T) {
+		rc, rdb := initStandaloneRedisc(t)
+		require.NoError(t, rc.Set(ctx, "k1", "v"))
+		count := rdb.CommandCount()
+		require.NoError(t, rc.Del(ctx, "k1"))
+		assert.Equal(t, 2, rdb.CommandCount()-count, "should skip the cleanup of tags")
+		assert.False(t, rdb.Exists("k1"))
+	})
+	t.Run("no expiration", func(t *testing.T) {
+		rc, rdb := initStandaloneRedisc(t)
+		require.NoError(t, rc.Set(ctx, "k1", "v", cache.WithTTL(time.Minute), cache.WithTags("tag")))
+		require.NoError(t, rc.Set(ctx, "k2", "v", cache.WithTTL(0), cache.WithTags("tag")))
+		require.NoError(t, rc.Set(ctx, "k3", "v", cache.WithTTL(time.Hour), cache.WithTags("tag")))
+		assert.Zero(t, rdb.TTL("cache:tag:tag"))
+	})
+	t.Run("failed set", func(t *testing.T) {
+		rc, rdb := initStandaloneRedisc(t)
+		require.Error(t, rc.Set(ctx, "k1", "v", cache.WithSetXX(), cache.WithTags("tag")))
+		assert.False(t, rdb.Exists("cache:tag:tag"))
+	})
+	t.Run("global", func(t *testing.T) {
+		rc, _ := initStandaloneRedisc(t)
+		require.NoError(t, cache.RegisterCache("tagged", rc))
+		t.Cleanup(func() {
+			cache.UnRegisterCache("tagged")
+		})
+		require.NoError(t, cache.SetDefault("tagged"))
+		require.NoError(t, cache.Set(ctx, "k1", "v", cache.WithTags("tag")))
+		require.NoError(t, cache.InvalidateTags(ctx, "tag"))
+		assert.False(t, cache.Has(ctx, "k1"))
+	})
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	newRedisc := func() *Redisc {
+		cfg := conf.NewFromStringMap(map[string]any{
+			"appName": "app",
+			"cache": map[string]any{
+				"addrs":        []string{mr.Addr()},
+				"local":        map[string]any{"size": 1000, "ttl": "1m"},
+				"invalidation": map[string]any{},
+			},
+		})
+		rc, err := New(cfg.Sub("cache"))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, rc.Close())
+		})
+		return rc
+	}
+	a, b := newRedisc(), newRedisc()
+	assert.Equal(t, "woocoo:cache:invalidate:app", a.invalidator.channel)
+	assert.Equal(t, 2, mr.PubSubNumSub("woocoo:cache:invalidate:app")["woocoo:cache:invalidate:app"])
+	fillLocal := func(key string) {
+		var v string
+		require.NoError(t, b.Get(ctx, key, &v))
+		require.True(t, b.local.Has(ctx, key))
+	}
+
+	t.Run("set", func(t *testing.T) {
+		require.NoError(t, a.Set(ctx, "key", "v1"))
+		fillLocal("key")
+		require.NoError(t, a.Set(ctx, "key", "v2"))
+		assert.Eventually(t, func() bool {
+			return !b.local.Has(ctx, "key")
+		}, time.Second, 10*time.Millisecond)
+		assert.True(t, a.local.Has(ctx, "key"), "should not invalidate itself")
+		var v string
+		require.NoError(t, b.Get(ctx, "key", &v))
+		assert.Equal(t, "v2", v)
+	})
+	t.Run("del", func(t *testing.T) {
+		require.NoError(t, a.Set(ctx, "del", "v"))
+		fillLocal("del")
+		require.NoError(t, a.Del(ctx, "del"))
+		assert.Eventually(t, func() bool {
+			return !b.local.Has(ctx, "del")
+		}, time.Second, 10*time.Millisecond)
+	})
+	t.Run("getter", func(t *testing.T) {
+		require.NoError(t, b.Set(ctx, "getter", "local", cache.WithSkip(cache.SkipRemote)))
+		var v string
+		require.NoError(t, a.Get(ctx, "getter", &v, cache.WithGetter(func(ctx context.Context, key string) (any, error) {
+			return "v", nil
+		})))
+		assert.Equal(t, "v", v)
+		assert.Never(t, func() bool {
+			return !b.local.Has(ctx, "getter")
+		}, 200*time.Millisecond, 10*time.Millisecond, "should not publish the filling of getter")
+	})
+	t.Run("tags", func(t *testing.T) {
+		require.NoError(t, a.Set(ctx, "tagged", "v", cache.WithTags("tag")))
+		fillLocal("tagged")
+		require.NoError(t, a.InvalidateTags(ctx, "tag"))
+		assert.Eventually(t, func() bool {
+			return !b.local.Has(ctx, "tagged")
+		}, time.Second, 10*time.Millisecond)
+	})
+	t.Run("reconnect", func(t *testing.T) {
+		require.NoError(t, b.Set(ctx, "local", "v", cache.WithSkip(cache.SkipRemote)))
+		require.True(t, b.local.Has(ctx, "local"))
+		mr.Close()
+		require.NoError(t, mr.Restart())
+		assert.Eventually(t, func() bool {
+			return !b.local.Has(ctx, "local")
+		}, 5*time.Second, 50*time.Millisecond, "should flush the local cache after reconnecting")
+	})
+}
+
+func TestCache_InvalidationConfig(t *testing.T) {
+	mr := miniredis.RunT(t)
+	t.Run("channel", func(t *testing.T) {
+		rc, err := New(conf.NewFromStringMap(map[string]any{
+			"addrs":        []string{mr.Addr()},
+			"invalidation": map[string]any{"channel": "my-channel"},
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "my-channel", rc.invalidator.channel)
+		assert.Nil(t, rc.invalidator.pubsub, "should not subscribe without local cache")
+		assert.NoError(t, rc.Close())
+	})
+	t.Run("unsupported client", func(t *testing.T) {
+		_, err := New(conf.NewFromStringMap(map[string]any{
+			"local":        map[string]any{"size": 1000},
+			"invalidation": map[string]any{},
+		}), func(r *Redisc) {
+			r.redis = redis.NewClient(&redis.Options{Addr: mr.Addr()}).Pipeline()
+		})
+		assert.ErrorContains(t, err, "pub/sub")
+	})
+	t.Run("close local", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "app.yaml")
+		write := func(ttl string) {
+			require.NoError(t, os.WriteFile(file, []byte(fmt.Sprintf(
+				"cache:\n  addrs: [%s]\n  local:\n    size: 100\n    ttl: %s\n", mr.Addr(), ttl)), 0600))
+		}
+		write("1m")
+		cnf := conf.New(conf.WithBaseDir(dir), conf.WithLocalPath(file)).Load()
+		rc, err := New(cnf.Sub("cache"))
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		write("2m")
+		require.NoError(t, cnf.TryReload())
+		assert.Equal(t, time.Minute, rc.local.TTL, "should close the local cache")
+	})
+	t.Run("disabled", func(t *testing.T) {
+		rc, err := New(conf.NewFromStringMap(map[string]any{
+			"addrs": []string{mr.Addr()},
+		}))
+		require.NoError(t, err)
+		assert.Nil(t, rc.invalidator)
+		assert.NoError(t, rc.Close())
+	})
+}
@@ -0,0 +1,88 @@
+package conf
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// Bind unmarshals the value of path into a new T, it is the shortcut of `cnf.Sub(path).Unmarshal(&v)` but returns
+// an error instead of panic if the path not exists. The path is relative to cnf, empty path means cnf itself, and it
+// can be a leaf value such as:
+//
+//	ttl, err := conf.Bind[time.Duration](cnf, "cache.ttl")
+func Bind[T any](cnf *Configuration, path string) (T, error) {
+	var v T
+	if path == "" {
+		return v, cnf.Unmarshal(&v)
+	}
+	if !cnf.IsSet(path) {
+		return v, fmt.Errorf("conf: bind %s: path not found", joinPath(cnf.prefix, path))
+	}
+	if _, ok := cnf.Parser().Get(path).(map[string]any); ok {
+		return v, cnf.Sub(path).Unmarshal(&v)
+	}
+	if err := cnf.Parser().Unmarshal(path, &v); err != nil {
+		return v, fmt.Errorf("conf: unmarshal %s: %w", joinPath(cnf.prefix, path), err)
+	}
+	return v, nil
+}
+
+// Value holds a T bound to a path of Configuration, and rebinds it after the value of the path changed by reloading,
+// such as Configuration.TryReload, Configuration.Watch or the reload signal of the application. It is safe for
+// concurrent use, use it instead of copying the configuration to package variables:
+//
+//	var opts *conf.Value[Options]
+//	opts, err = conf.NewValue[Options](cnf, "handler")
+//	...
+//	timeout := opts.Load().Timeout
+//
+// If rebinding fails or the path is removed, the previous value is kept. Call Close when the Value is no longer used,
+// otherwise it is kept by the root Configuration and rebinds forever.
+type Value[T any] struct {
+	v    atomic.Pointer[T]
+	root *Configuration
+	// path is the full path from the root.
+	path string
+	// unsubscribe cancels the rebinding.
+	unsubscribe func()
+	closeOnce   sync.Once
+}
+
+// NewValue binds the path of cnf to a Value, it returns the error of the first binding.
+func NewValue[T any](cnf *Configuration, path string) (*Value[T], error) {
+	v, err := Bind[T](cnf, path)
+	if err != nil {
+		return nil, err
+	}
+	val := &Value[T]{
+		root: cnf.Root(),
+		path: joinPath(cnf.prefix, path),
+	}
+	val.v.Store(&v)
+	val.unsubscribe = cnf.Subscribe(path, func(_, _ *Configuration) {
+		val.rebind()
+	})
+	return val, nil
+}
+
+// Close stops rebinding the value, the current value is still returned by Load.
+func (v *Value[T]) Close() error {
+	v.closeOnce.Do(v.unsubscribe)
+	return nil
+}
+
+// Load returns the current value.
+func (v *Value[T]) Load() T {
+	return *v.v.Load()
+}
+
+func (v *Value[T]) rebind() {
+	nv, err := Bind[T](v.root, v.path)
+	if err != nil {
+		log.Printf("conf: rebind value of %q error, keep the previous value: %v", v.path, err)
+		return
+	}
+	v.v.Store(&nv)
+}
@@ -0,0 +1,117 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindOptions struct {
+	Addr    string        `json:"addr" validate:"required"`
+	Timeout time.Duration `json:"timeout" default:"1s"`
+}
+
+func TestBind(t *testing.T) {
+	cnf := NewFromBytes([]byte(`
+web:
+  server:
+    addr: :8080
+cache:
+  ttl: 1m
+  tags: [a, b]
+`))
+	t.Run("struct", func(t *testing.T) {
+		v, err := Bind[bindOptions](cnf, "web.server")
+		require.NoError(t, err)
+		assert.Equal(t, bindOptions{Addr: ":8080"}, v)
+		p, err := Bind[*bindOptions](cnf.Sub("web"), "server")
+		require.NoError(t, err)
+		assert.Equal(t, ":8080", p.Addr)
+	})
+	t.Run("leaf", func(t *testing.T) {
+		ttl, err := Bind[time.Duration](cnf, "cache.ttl")
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, ttl)
+		tags, err := Bind[[]string](cnf.Sub("cache"), "tags")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, tags)
+		_, err = Bind[int](cnf, "web.server.addr")
+		assert.ErrorContains(t, err, "conf: unmarshal web.server.addr")
+	})
+	t.Run("missing", func(t *testing.T) {
+		_, err := Bind[bindOptions](cnf.Sub("web"), "client")
+		assert.EqualError(t, err, "conf: bind web.client: path not found")
+	})
+	t.Run("strict", func(t *testing.T) {
+		cnf := NewFromBytes([]byte("server:\n  addr: :8080\n"), WithStrict())
+		v, err := Bind[bindOptions](cnf, "server")
+		require.NoError(t, err)
+		assert.Equal(t, bindOptions{Addr: ":8080", Timeout: time.Second}, v)
+		cnf = NewFromBytes([]byte("server:\n  timeout: 2s\n"), WithStrict())
+		_, err = Bind[bindOptions](cnf, "server")
+		assert.ErrorContains(t, err, "conf: server.addr: failed on the 'required' validation")
+	})
+}
+
+func TestValue(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("web:\n  server:\n    addr: :8080\n    timeout: 1s\n"), 0600))
+	cnf := New(WithBaseDir(dir), WithLocalPath(file)).Load()
+
+	opts, err := NewValue[bindOptions](cnf.Sub("web"), "server")
+	require.NoError(t, err)
+	addr, err := NewValue[string](cnf, "web.server.addr")
+	require.NoError(t, err)
+	assert.Equal(t, bindOptions{Addr: ":8080", Timeout: time.Second}, opts.Load())
+	assert.Equal(t, ":8080", addr.Load())
+
+	_, err = NewValue[bindOptions](cnf, "web.client")
+	assert.Error(t, err)
+
+	t.Run("reload", func(t *testing.T) {
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					_ = opts.Load().Addr
+				}
+			}
+		}()
+		require.NoError(t, os.WriteFile(file, []byte("web:\n  server:\n    addr: :9090\n    timeout: 2s\n"), 0600))
+		require.NoError(t, cnf.TryReload())
+		close(done)
+		wg.Wait()
+		assert.Equal(t, bindOptions{Addr: ":9090", Timeout: time.Second * 2}, opts.Load())
+		assert.Equal(t, ":9090", addr.Load())
+	})
+	t.Run("keep previous", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte("web:\n  server:\n    addr: :7070\n    timeout: x\n"), 0600))
+		require.NoError(t, cnf.TryReload())
+		assert.Equal(t, bindOptions{Addr: ":9090", Timeout: time.Second * 2}, opts.Load())
+		assert.Equal(t, ":7070", addr.Load())
+
+		require.NoError(t, os.WriteFile(file, []byte("appName: test\n"), 0600))
+		require.NoError(t, cnf.TryReload())
+		assert.Equal(t, ":7070", addr.Load())
+	})
+	t.Run("close", func(t *testing.T) {
+		require.NoError(t, addr.Close())
+		require.NoError(t, addr.Close())
+		require.NoError(t, os.WriteFile(file, []byte("web:\n  server:\n    addr: :6060\n"), 0600))
+		require.NoError(t, cnf.TryReload())
+		assert.Equal(t, ":7070", addr.Load(), "should not rebind after closed")
+		assert.Equal(t, ":6060", opts.Load().Addr)
+	})
+}
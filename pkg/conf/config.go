@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/knadh/koanf/v2"
@@ -24,6 +25,8 @@ type Configurable interface {
 
 const (
 	baseDirEnv = "WOOCOO_BASEDIR"
+	// ProfileEnv is the env name of the active profile, it is used if the profile is not set by WithProfile.
+	ProfileEnv = "WOOCOO_PROFILE"
 )
 
 var (
@@ -34,9 +37,20 @@ var (
 type (
 	// Configuration hold settings of the component.
 	Configuration struct {
-		opts        options
-		parser      *Parser
-		root        *Configuration
+		opts options
+		// parser is swapped by TryReload, it is published atomically for the concurrent readers.
+		parser atomic.Pointer[Parser]
+		root   *Configuration
+		// prefix is the full path from the root
+		prefix string
+		// subs is the change subscribers, only the root holds it.
+		subs *subscribers
+		// watcher watches the files of the configuration, only the root holds it.
+		watcher *fileWatcher
+		// sources are the loaded sources, only the root holds it.
+		sources []*loadedSource
+		// provenance records the values set while loading, only the root holds it.
+		provenance  []Provenance
 		Development bool
 	}
 	// AppConfiguration is the application level configuration,include all of component's configurations
@@ -71,12 +85,16 @@ func init() {
 //	cnf := conf.New().Load()
 func New(opts ...Option) *Configuration {
 	cnf := &Configuration{
-		opts:   defaultOptions,
-		parser: NewParser(),
+		opts: defaultOptions,
+		subs: newSubscribers(),
 	}
+	cnf.parser.Store(NewParser())
 	for _, o := range opts {
 		o(&cnf.opts)
 	}
+	if cnf.opts.profile == "" {
+		cnf.opts.profile = os.Getenv(ProfileEnv)
+	}
 
 	if cnf.opts.global {
 		cnf.AsGlobal()
@@ -105,7 +123,7 @@ func NewFromParse(parser *Parser, opts ...Option) *Configuration {
 	cnf := New(opts...)
 	// clear the local path
 	cnf.opts.localPath = ""
-	cnf.parser = parser
+	cnf.parser.Store(parser)
 	return cnf
 }
 
@@ -132,25 +150,27 @@ func (c *Configuration) Load() *Configuration {
 	if err := c.loadInternal(); err != nil {
 		panic("config load error:" + err.Error())
 	}
-	c.Development = c.parser.k.Bool("development")
+	c.Development = c.Parser().k.Bool("development")
 	return c
 }
 
-// Reload configuration,if only work for local path configuration
+// Reload configuration,if only work for local path configuration. It panics if load failed,
+// use TryReload at runtime instead.
 func (c *Configuration) Reload() {
-	c.parser = nil
+	c.parser.Store(nil)
 	c.Load()
 }
 
 // load configuration,if the RemoteProvider is set,will ignore local configuration
 func (c *Configuration) loadInternal() (err error) {
 	// if parser is nil, use default local config file
-	if c.parser == nil {
-		c.parser = NewParser()
+	if c.Parser() == nil {
+		c.parser.Store(NewParser())
 	}
+	c.provenance = nil
 	if c.opts.localPath != "" {
-		TryLoadEnvFromFile(filepath.Dir(c.opts.localPath), "")
-		err = c.parser.LoadFileWithEnv(c.opts.localPath)
+		TryLoadEnvFromFile(filepath.Dir(c.opts.localPath), c.opts.profile)
+		err = c.loadFile(OriginFile, c.opts.localPath)
 		if err != nil {
 			return err
 		}
@@ -170,11 +190,40 @@ func (c *Configuration) loadInternal() (err error) {
 	copyifs := make([]string, len(c.opts.includeFiles))
 	copy(copyifs, c.opts.includeFiles)
 	for _, attach := range copyifs {
-		if err = c.parser.LoadFileWithEnv(attach); err != nil {
+		if err = c.loadFile(OriginInclude, attach); err != nil {
 			return err
 		}
 	}
-	return err
+	// the profile file overrides the local file and the include files.
+	if pf := c.profilePath(); pf != "" {
+		if _, err = os.Stat(pf); err == nil {
+			if err = c.loadFile(OriginProfile, pf); err != nil {
+				return err
+			}
+		}
+	}
+	if err = c.loadSources(); err != nil {
+		return err
+	}
+	if err = c.applyOverrides(); err != nil {
+		return err
+	}
+	return c.resolveSecrets()
+}
+
+// profilePath returns the profile file path of the local file, such as "etc/app.test.yaml" for profile "test",
+// returns empty if no active profile or no local file.
+func (c *Configuration) profilePath() string {
+	if c.opts.profile == "" || c.opts.localPath == "" {
+		return ""
+	}
+	ext := filepath.Ext(c.opts.localPath)
+	return strings.TrimSuffix(c.opts.localPath, ext) + "." + c.opts.profile + ext
+}
+
+// Profile returns the active profile, it is set by WithProfile or the env WOOCOO_PROFILE.
+func (c *Configuration) Profile() string {
+	return c.opts.profile
 }
 
 // tryAbs return the absolute path by the base dir if path is a relative path.
@@ -207,7 +256,7 @@ func (c *Configuration) Merge(b []byte) error {
 	if err != nil {
 		return err
 	}
-	err = c.parser.k.Merge(p.k)
+	err = c.Parser().k.Merge(p.k)
 	if err != nil {
 		return err
 	}
@@ -226,12 +275,12 @@ func (c *Configuration) SetBaseDir(dir string) {
 
 // Parser return configuration operator
 func (c *Configuration) Parser() *Parser {
-	return c.parser
+	return c.parser.Load()
 }
 
 // ParserOperator return the underlying parser that converts bytes to map
 func (c *Configuration) ParserOperator() *koanf.Koanf {
-	return c.parser.k
+	return c.Parser().k
 }
 
 // Sub return a new Configuration by a sub node.return current node if path empty,panic if path not found.
@@ -247,21 +296,31 @@ func (c *Configuration) Sub(path string) *Configuration {
 	}
 	nc := &Configuration{
 		opts:        c.opts,
-		parser:      p,
+		prefix:      joinPath(c.prefix, path),
 		Development: c.Development,
 	}
+	nc.parser.Store(p)
 	c.passRoot(nc)
 	return nc
 }
 
 // CutFromOperator return a new copied Configuration but replace the parser by koanf operator.
 func (c *Configuration) CutFromOperator(kf *koanf.Koanf) *Configuration {
-	nf := *c
-	nf.parser = &Parser{
-		k: kf,
+	nf := &Configuration{
+		opts:        c.opts,
+		root:        c.root,
+		prefix:      c.prefix,
+		subs:        c.subs,
+		watcher:     c.watcher,
+		sources:     c.sources,
+		provenance:  c.provenance,
+		Development: c.Development,
 	}
-	c.passRoot(&nf)
-	return &nf
+	nf.parser.Store(&Parser{
+		k: kf,
+	})
+	c.passRoot(nf)
+	return nf
 }
 
 func (c *Configuration) passRoot(sub *Configuration) {
@@ -373,8 +432,19 @@ func (c *Configuration) Version() string {
 //		    BaseConfig `yaml:",inline"`
 //	     // should not *BaseConfig
 //	 }
+//
+// If the strict mode is enabled by WithStrict, the `default` and `validate` tags take effect, and the keys
+// not matching any field are reported.
 func (c *Configuration) Unmarshal(dst any) (err error) {
-	return c.parser.Unmarshal("", dst)
+	if c.opts.strict {
+		return c.unmarshalStrict(dst)
+	}
+	return c.Parser().Unmarshal("", dst)
+}
+
+// IsStrict reports whether the strict mode of Unmarshal is enabled.
+func (c *Configuration) IsStrict() bool {
+	return c.opts.strict
 }
 
 // Abs returns the absolute path by the base dir if path is a relative path
@@ -393,42 +463,42 @@ func (c *Configuration) Abs(path string) string {
 
 func Get(path string) any { return global.Get(path) }
 func (c *Configuration) Get(key string) any {
-	return c.parser.Get(key)
+	return c.Parser().Get(key)
 }
 
 func Bool(path string) bool { return global.Bool(path) }
 func (c *Configuration) Bool(path string) bool {
-	return c.parser.k.Bool(path)
+	return c.Parser().k.Bool(path)
 }
 
 func Float64(path string) float64 { return global.Float64(path) }
 func (c *Configuration) Float64(path string) float64 {
-	return c.parser.k.Float64(path)
+	return c.Parser().k.Float64(path)
 }
 
 func Int(path string) int { return global.Int(path) }
 func (c *Configuration) Int(path string) int {
-	return c.parser.k.Int(path)
+	return c.Parser().k.Int(path)
 }
 
 func IntSlice(path string) []int { return global.IntSlice(path) }
 func (c *Configuration) IntSlice(path string) []int {
-	return c.parser.k.Ints(path)
+	return c.Parser().k.Ints(path)
 }
 
 func String(path string) string { return global.String(path) }
 func (c *Configuration) String(path string) string {
-	return c.parser.k.String(path)
+	return c.Parser().k.String(path)
 }
 
 func StringMap(path string) map[string]string { return global.StringMap(path) }
 func (c *Configuration) StringMap(path string) map[string]string {
-	return c.parser.k.StringMap(path)
+	return c.Parser().k.StringMap(path)
 }
 
 func StringSlice(path string) []string { return global.StringSlice(path) }
 func (c *Configuration) StringSlice(path string) []string {
-	return c.parser.k.Strings(path)
+	return c.Parser().k.Strings(path)
 }
 
 // Time return time by layout, eg: 2006-01-02 15:04:05
@@ -436,12 +506,12 @@ func (c *Configuration) StringSlice(path string) []string {
 // if config is init from a map value of time.Time, the layout will be: `2006-01-02 15:04:05 -0700 MST`
 func Time(path string, layout string) time.Time { return global.Time(path, layout) }
 func (c *Configuration) Time(path string, layout string) time.Time {
-	return c.parser.k.Time(path, layout)
+	return c.Parser().k.Time(path, layout)
 }
 
 func Duration(path string) time.Duration { return global.Duration(path) }
 func (c *Configuration) Duration(path string) time.Duration {
-	return c.parser.k.Duration(path)
+	return c.Parser().k.Duration(path)
 }
 
 // IsSet check if the key is set
@@ -449,13 +519,13 @@ func IsSet(path string) bool { return global.IsSet(path) }
 
 // IsSet check if the key is set
 func (c *Configuration) IsSet(path string) bool {
-	return c.parser.IsSet(path)
+	return c.Parser().IsSet(path)
 }
 
 // AllSettings return all settings
 func AllSettings() map[string]any { return global.AllSettings() }
 func (c *Configuration) AllSettings() map[string]any {
-	return c.parser.k.Raw()
+	return c.Parser().k.Raw()
 }
 
 // Join paths
@@ -469,6 +539,17 @@ func Join(ps ...string) string {
 	return strings.Join(ps, KeyDelimiter)
 }
 
+// joinPath joins the prefix and the path, ignores the empty one.
+func joinPath(prefix, path string) string {
+	switch {
+	case prefix == "":
+		return path
+	case path == "":
+		return prefix
+	}
+	return prefix + KeyDelimiter + path
+}
+
 // ----------------------------------------------------------------------------
 
 // Global return default(global) Configuration instance
@@ -190,6 +190,47 @@ func TestConfiguration_Exists(t *testing.T) {
 	assert.Contains(t, cnf.LocalPath(), "app.yaml")
 }
 
+func TestConfiguration_Profile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+includeFiles:
+  - attach.yaml
+web:
+  addr: :8080
+  host: ${PROFILE_HOST}
+cache:
+  ttl: 1m
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "attach.yaml"), []byte("cache:\n  ttl: 2m\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.test.yaml"), []byte("web:\n  addr: :9090\ncache:\n  ttl: 3m\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env.test"), []byte("PROFILE_HOST=test.local\n"), 0600))
+	t.Cleanup(func() {
+		os.Unsetenv("PROFILE_HOST")
+	})
+	t.Run("option", func(t *testing.T) {
+		t.Setenv(ProfileEnv, "prod")
+		cnf := New(WithBaseDir(dir), WithLocalPath(file), WithProfile("test")).Load()
+		assert.Equal(t, "test", cnf.Profile())
+		assert.Equal(t, "test", cnf.Sub("web").Profile())
+		assert.Equal(t, ":9090", cnf.String("web.addr"))
+		assert.Equal(t, "test.local", cnf.String("web.host"))
+		assert.Equal(t, time.Minute*3, cnf.Duration("cache.ttl"))
+	})
+	t.Run("env", func(t *testing.T) {
+		t.Setenv(ProfileEnv, "test")
+		cnf := New(WithBaseDir(dir), WithLocalPath(file)).Load()
+		assert.Equal(t, "test", cnf.Profile())
+		assert.Equal(t, ":9090", cnf.String("web.addr"))
+	})
+	t.Run("no profile file", func(t *testing.T) {
+		cnf := New(WithBaseDir(dir), WithLocalPath(file), WithProfile("dev")).Load()
+		assert.Equal(t, "dev", cnf.Profile())
+		assert.Equal(t, ":8080", cnf.String("web.addr"))
+		assert.Equal(t, time.Minute*2, cnf.Duration("cache.ttl"))
+	})
+}
+
 func TestConfiguration_Load(t *testing.T) {
 	type fields struct {
 		cnf *Configuration
@@ -303,11 +344,11 @@ func TestConfiguration_Unmarshal(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c := &Configuration{
 				opts:        tt.fields.opts,
-				parser:      tt.fields.parser,
 				Development: tt.fields.Development,
 				root:        tt.fields.root,
 			}
-			tt.wantErr(t, c.parser.Unmarshal(tt.args.key, &tt.args.dst), tt.args.dst)
+			c.parser.Store(tt.fields.parser)
+			tt.wantErr(t, c.Parser().Unmarshal(tt.args.key, &tt.args.dst), tt.args.dst)
 		})
 	}
 }
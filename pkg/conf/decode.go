@@ -0,0 +1,117 @@
+package conf
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// ByteSize is a size in bytes, it can be decoded from a number of bytes or a string with unit such as
+// "512KB", "10MB", "1GiB". The units are case-insensitive, KB and KiB are both 1024 bytes.
+type ByteSize int64
+
+const (
+	_           = iota
+	KB ByteSize = 1 << (10 * iota)
+	MB
+	GB
+	TB
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"":  1,
+	"b": 1,
+	"k": KB, "kb": KB, "kib": KB,
+	"m": MB, "mb": MB, "mib": MB,
+	"g": GB, "gb": GB, "gib": GB,
+	"t": TB, "tb": TB, "tib": TB,
+}
+
+// ParseByteSize parses a string such as "10MB" to ByteSize.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	num, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	mul, ok := byteSizeUnits[unit]
+	if !ok || num == "" {
+		return 0, fmt.Errorf("conf: invalid byte size %q", s)
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("conf: invalid byte size %q", s)
+	}
+	return ByteSize(f * float64(mul)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	v, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// String returns the size with the largest unit which divides it exactly.
+func (b ByteSize) String() string {
+	for _, u := range []struct {
+		unit string
+		size ByteSize
+	}{{"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB}} {
+		if b != 0 && b%u.size == 0 {
+			return strconv.FormatInt(int64(b/u.size), 10) + u.unit
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// stringToLocationHookFunc decodes a string such as "Asia/Shanghai" to time.Location or *time.Location.
+func stringToLocationHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		switch t {
+		case reflect.TypeOf(time.Location{}):
+			loc, err := time.LoadLocation(data.(string))
+			if err != nil {
+				return nil, err
+			}
+			return *loc, nil
+		case reflect.TypeOf(&time.Location{}):
+			return time.LoadLocation(data.(string))
+		}
+		return data, nil
+	}
+}
+
+// stringToURLHookFunc decodes a string to url.URL or *url.URL.
+func stringToURLHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		switch t {
+		case reflect.TypeOf(url.URL{}):
+			u, err := url.Parse(data.(string))
+			if err != nil {
+				return nil, err
+			}
+			return *u, nil
+		case reflect.TypeOf(&url.URL{}):
+			return url.Parse(data.(string))
+		}
+		return data, nil
+	}
+}
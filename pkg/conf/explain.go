@@ -0,0 +1,193 @@
+package conf
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// RedactedValue is the mask of the redacted values.
+const RedactedValue = "******"
+
+// DefaultRedactPatterns are the default patterns of the secret-looking keys, see WithRedactPatterns.
+var DefaultRedactPatterns = []string{"password", "secret", "key", "token"}
+
+// Origin is the kind of the location which sets a configuration value.
+type Origin string
+
+const (
+	// OriginFile is the local file.
+	OriginFile Origin = "file"
+	// OriginInclude is an include file.
+	OriginInclude Origin = "include"
+	// OriginProfile is the profile file.
+	OriginProfile Origin = "profile"
+	// OriginSource is a remote source.
+	OriginSource Origin = "source"
+	// OriginEnv is an env var of WithEnvPrefix.
+	OriginEnv Origin = "env"
+	// OriginFlag is an arg of WithArgs.
+	OriginFlag Origin = "flag"
+	// OriginSecret is a resolved secret reference or encrypted value, the value is not recorded.
+	OriginSecret Origin = "secret"
+)
+
+// Provenance records a value set to a key while loading.
+type Provenance struct {
+	// Key is the full path of the key, the index of a slice is like "routerGroups[0]".
+	Key    string `json:"key"`
+	Origin Origin `json:"origin"`
+	// Location is the file path, the source scheme, the env name or the arg.
+	Location string `json:"location"`
+	Value    any    `json:"value,omitempty"`
+}
+
+// track records the flattened values of k set by the location.
+func (c *Configuration) track(origin Origin, location string, k *koanf.Koanf) {
+	all := k.All()
+	keys := make([]string, 0, len(all))
+	for key := range all {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c.provenance = append(c.provenance, Provenance{Key: key, Origin: origin, Location: location, Value: all[key]})
+	}
+}
+
+// loadFile loads the file and records the provenance of its values.
+func (c *Configuration) loadFile(origin Origin, path string) error {
+	lp := NewParser()
+	if err := lp.LoadFileWithEnv(path); err != nil {
+		return err
+	}
+	c.track(origin, path, lp.k)
+	return c.Parser().k.Merge(lp.k)
+}
+
+// formatKeyPath joins the path, the index of a slice is formatted like "[0]".
+func formatKeyPath(path []string) string {
+	var sb strings.Builder
+	for i, p := range path {
+		if _, err := strconv.Atoi(p); err == nil && i > 0 {
+			sb.WriteString("[" + p + "]")
+			continue
+		}
+		if i > 0 {
+			sb.WriteString(KeyDelimiter)
+		}
+		sb.WriteString(p)
+	}
+	return sb.String()
+}
+
+// Explain reports the values set to the key and its related keys while loading, ordered by the precedence,
+// so the last one is the effective value. The related keys are the parents and the children of the key, such as
+// "web.engine.routerGroups[0].default.basePath" set by an env is related to "web.engine.routerGroups".
+//
+// The key is relative to the current Configuration. The values of secret-looking keys are redacted. The values
+// set by Parser.Set at runtime are not recorded.
+func (c *Configuration) Explain(key string) []Provenance {
+	root := c.Root()
+	full := joinPath(c.prefix, key)
+	patterns := c.redactPatterns()
+	var res []Provenance
+	for _, p := range root.provenance {
+		if !isRelatedKey(full, p.Key) {
+			continue
+		}
+		if p.Value != nil && matchRedact(p.Key, patterns) {
+			p.Value = RedactedValue
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+func isRelatedKey(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if a == "" || a == b {
+		return true
+	}
+	return strings.HasPrefix(b, a) && (b[len(a)] == '.' || b[len(a)] == '[')
+}
+
+// Dump returns the merged configuration of the current node, the values of secret-looking keys are masked
+// if redact is true. The patterns of the secret-looking keys are set by WithRedactPatterns.
+func (c *Configuration) Dump(redact bool) map[string]any {
+	raw := c.Parser().k.Raw()
+	if !redact {
+		return raw
+	}
+	return redactMap(raw, c.redactPatterns())
+}
+
+func (c *Configuration) redactPatterns() []*regexp.Regexp {
+	if c.opts.redactPatterns != nil {
+		return c.opts.redactPatterns
+	}
+	return compileRedactPatterns(DefaultRedactPatterns)
+}
+
+func compileRedactPatterns(patterns []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			panic(fmt.Errorf("conf: invalid redact pattern %q: %w", p, err))
+		}
+		res[i] = re
+	}
+	return res
+}
+
+// RedactMap returns a copy of the settings which masks the values of the keys matching any of the patterns,
+// the patterns are case-insensitive regular expressions.
+func RedactMap(settings map[string]any, patterns ...string) map[string]any {
+	return redactMap(settings, compileRedactPatterns(patterns))
+}
+
+func redactMap(settings map[string]any, patterns []*regexp.Regexp) map[string]any {
+	res := make(map[string]any, len(settings))
+	for k, v := range settings {
+		if matchRedact(k, patterns) {
+			res[k] = RedactedValue
+			continue
+		}
+		res[k] = redactValue(v, patterns)
+	}
+	return res
+}
+
+func redactValue(v any, patterns []*regexp.Regexp) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		return redactMap(vv, patterns)
+	case []any:
+		res := make([]any, len(vv))
+		for i, item := range vv {
+			res[i] = redactValue(item, patterns)
+		}
+		return res
+	}
+	return v
+}
+
+// matchRedact reports whether the last part of the key matches any of the patterns.
+func matchRedact(key string, patterns []*regexp.Regexp) bool {
+	if i := strings.LastIndexAny(key, ".]"); i >= 0 {
+		key = key[i+1:]
+	}
+	for _, re := range patterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfiguration_Explain(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	include := filepath.Join(dir, "include.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+includeFiles:
+  - include.yaml
+web:
+  server:
+    addr: :8080
+  engine:
+    routerGroups:
+      - default:
+          basePath: /
+cache:
+  ttl: 1m
+db:
+  password: "${secret:env:SECRET_DB_PASSWORD}"
+`), 0600))
+	require.NoError(t, os.WriteFile(include, []byte("cache:\n  ttl: 2m\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.test.yaml"), []byte("cache:\n  ttl: 3m\n"), 0600))
+	t.Setenv("SECRET_DB_PASSWORD", "pwd")
+	t.Setenv("EXPLAIN_WEB_ENGINE_ROUTERGROUPS_0_DEFAULT_BASEPATH", "/api")
+	t.Setenv("EXPLAIN_CACHE_TTL", "4m")
+
+	cnf := New(WithBaseDir(dir), WithLocalPath(file), WithProfile("test"), WithEnvPrefix("EXPLAIN"),
+		WithSource(&mockSource{values: map[string]any{"cache": map[string]any{"size": 10}}}),
+		WithArgs([]string{"--set", "web.server.addr=:9090"}),
+	).Load()
+
+	t.Run("layers", func(t *testing.T) {
+		got := cnf.Explain("cache.ttl")
+		require.Len(t, got, 4)
+		assert.Equal(t, Provenance{Key: "cache.ttl", Origin: OriginFile, Location: file, Value: "1m"}, got[0])
+		assert.Equal(t, Provenance{Key: "cache.ttl", Origin: OriginInclude, Location: include, Value: "2m"}, got[1])
+		assert.Equal(t, OriginProfile, got[2].Origin)
+		assert.Equal(t, Provenance{Key: "cache.ttl", Origin: OriginEnv, Location: "EXPLAIN_CACHE_TTL", Value: "4m"}, got[3])
+
+		got = cnf.Sub("cache").Explain("size")
+		require.Len(t, got, 1)
+		assert.Equal(t, Provenance{Key: "cache.size", Origin: OriginSource, Location: "*conf.mockSource", Value: 10}, got[0])
+
+		got = cnf.Explain("web.server.addr")
+		require.Len(t, got, 2)
+		assert.Equal(t, Provenance{Key: "web.server.addr", Origin: OriginFlag, Location: "--set web.server.addr", Value: ":9090"}, got[1])
+	})
+	t.Run("related", func(t *testing.T) {
+		got := cnf.Explain("web.engine.routerGroups")
+		require.Len(t, got, 2)
+		assert.Equal(t, OriginFile, got[0].Origin)
+		assert.Equal(t, "web.engine.routerGroups[0].default.basePath", got[1].Key)
+		assert.Equal(t, OriginEnv, got[1].Origin)
+		assert.Len(t, cnf.Explain("web"), 4)
+		assert.Empty(t, cnf.Explain("web.serv"))
+		assert.Empty(t, cnf.Explain("none"))
+	})
+	t.Run("secret", func(t *testing.T) {
+		got := cnf.Explain("db.password")
+		require.Len(t, got, 2)
+		assert.Equal(t, RedactedValue, got[0].Value)
+		assert.Equal(t, Provenance{Key: "db.password", Origin: OriginSecret}, got[1])
+
+		setCnf := New(WithBaseDir(dir), WithLocalPath(file), WithArgs([]string{"--set", "db.password=hunter2"})).Load()
+		got = setCnf.Explain("db.password")
+		require.Len(t, got, 2)
+		assert.Equal(t, Provenance{Key: "db.password", Origin: OriginFlag, Location: "--set db.password", Value: RedactedValue}, got[1])
+		assert.NotContains(t, fmt.Sprint(got), "hunter2")
+	})
+	t.Run("reload", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(include, []byte("cache:\n  size: 20\n"), 0600))
+		require.NoError(t, cnf.TryReload())
+		got := cnf.Explain("cache.size")
+		require.Len(t, got, 2)
+		assert.Equal(t, OriginInclude, got[0].Origin)
+		assert.Equal(t, OriginSource, got[1].Origin)
+	})
+}
+
+func TestConfiguration_Dump(t *testing.T) {
+	cnf := NewFromBytes([]byte(`
+db:
+  dsn: root@tcp(localhost)/test
+  password: pwd
+clients:
+  - apiKey: k1
+    name: a
+`))
+	assert.Equal(t, "pwd", cnf.Dump(false)["db"].(map[string]any)["password"])
+	dump := cnf.Dump(true)
+	assert.Equal(t, map[string]any{"dsn": "root@tcp(localhost)/test", "password": RedactedValue}, dump["db"])
+	assert.Equal(t, []any{map[string]any{"apiKey": RedactedValue, "name": "a"}}, dump["clients"])
+	assert.Equal(t, "pwd", cnf.String("db.password"), "should not change the configuration")
+
+	cnf = NewFromBytes([]byte("db:\n  dsn: x\n  password: pwd\n"), WithRedactPatterns("^dsn$"))
+	assert.Equal(t, map[string]any{"dsn": RedactedValue, "password": "pwd"}, cnf.Sub("db").Dump(true))
+	assert.Panics(t, func() {
+		WithRedactPatterns("(")
+	})
+	assert.Equal(t, map[string]any{"a": map[string]any{"b": RedactedValue}}, RedactMap(map[string]any{"a": map[string]any{"b": 1}}, "B"))
+}
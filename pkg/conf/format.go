@@ -0,0 +1,64 @@
+package conf
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/knadh/koanf/parsers/dotenv"
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/v2"
+)
+
+// The built-in formats of configuration files.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+	// FormatDotenv is the dotenv format, the key is the path split by ".", such as:
+	//
+	//	web.server.addr=:8080
+	//	cache.tags=a,b
+	FormatDotenv = "env"
+)
+
+var (
+	formatMu sync.RWMutex
+	formats  = map[string]koanf.Parser{
+		FormatYAML:   yaml.Parser(),
+		"yml":        yaml.Parser(),
+		FormatJSON:   json.Parser(),
+		FormatTOML:   toml.Parser(),
+		FormatDotenv: dotenv.ParserEnv("", KeyDelimiter, nil),
+	}
+)
+
+// RegisterFormat registers a koanf.Parser for the format, the format is the file extension without dot.
+func RegisterFormat(format string, p koanf.Parser) {
+	formatMu.Lock()
+	defer formatMu.Unlock()
+	formats[strings.ToLower(format)] = p
+}
+
+// GetFormat returns the koanf.Parser of the format, such as "yaml", "json", "toml" and "env".
+// It can be used to export the configuration by Parser.ToBytes.
+func GetFormat(format string) (koanf.Parser, error) {
+	formatMu.RLock()
+	defer formatMu.RUnlock()
+	p, ok := formats[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("conf: unsupported format %q", format)
+	}
+	return p, nil
+}
+
+// parserByPath returns the koanf.Parser by the extension of the file, YAML is used if the extension is unknown.
+func parserByPath(path string) koanf.Parser {
+	if p, err := GetFormat(strings.TrimPrefix(filepath.Ext(path), ".")); err == nil {
+		return p
+	}
+	return yaml.Parser()
+}
@@ -0,0 +1,81 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfiguration_Formats(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TEST_FORMAT_HOST", "woocoo.com")
+	files := map[string]string{
+		"app.json": `{
+  "appName": "json",
+  "includeFiles": ["web.toml", "cache.yml", "extra.env"],
+  "web": {"server": {"addr": ":8080"}}
+}`,
+		"web.toml": `
+[web.server]
+addr = ":9090"
+host = "${TEST_FORMAT_HOST}"
+
+[[web.engine.routerGroups]]
+[web.engine.routerGroups.default]
+basePath = "/"
+`,
+		"cache.yml": "cache:\n  ttl: 1m\n",
+		"extra.env": "cache.ttl=2m\ncache.tags=a,b\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	cnf := New(WithBaseDir(dir), WithLocalPath(filepath.Join(dir, "app.json"))).Load()
+	assert.Equal(t, "json", cnf.AppName())
+	assert.Equal(t, ":9090", cnf.String("web.server.addr"))
+	assert.Equal(t, "woocoo.com", cnf.String("web.server.host"))
+	assert.Equal(t, time.Minute*2, cnf.Duration("cache.ttl"))
+	var cache struct {
+		Tags []string `json:"tags"`
+	}
+	require.NoError(t, cnf.Sub("cache").Unmarshal(&cache))
+	assert.Equal(t, []string{"a", "b"}, cache.Tags)
+	cnf.Each("web.engine.routerGroups", func(root string, sub *Configuration) {
+		assert.Equal(t, "default", root)
+		assert.Equal(t, "/", sub.String("basePath"))
+	})
+
+	t.Run("ToBytes", func(t *testing.T) {
+		for _, format := range []string{FormatYAML, FormatJSON, FormatTOML, FormatDotenv} {
+			t.Run(format, func(t *testing.T) {
+				p, err := GetFormat(format)
+				require.NoError(t, err)
+				bs, err := cnf.Parser().ToBytes(p)
+				require.NoError(t, err)
+				file := filepath.Join(dir, "export."+format)
+				require.NoError(t, os.WriteFile(file, bs, 0600))
+				np, err := NewParserFromFile(file)
+				require.NoError(t, err)
+				assert.Equal(t, ":9090", np.k.String("web.server.addr"))
+				assert.Equal(t, "json", np.k.String("appName"))
+				assert.Equal(t, "a,b", np.k.String("cache.tags"), string(bs))
+				assert.Equal(t, "2m", np.k.String("cache.ttl"))
+			})
+		}
+	})
+	t.Run("unknown", func(t *testing.T) {
+		_, err := GetFormat("xml")
+		assert.ErrorContains(t, err, "unsupported format")
+		RegisterFormat("conf", json.Parser())
+		file := filepath.Join(dir, "app.conf")
+		require.NoError(t, os.WriteFile(file, []byte(`{"a": 1}`), 0600))
+		np, err := NewParserFromFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, 1, np.k.Int("a"))
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"time"
 )
 
 // configuration detail
@@ -12,8 +14,24 @@ type options struct {
 	localPath    string
 	basedir      string
 	includeFiles []string
+	// optionIncludeFiles are the include files set by WithIncludeFiles, reloading starts with them.
+	optionIncludeFiles []string
 	// use parser global
 	global bool
+	// profile is the active profile, such as "dev", "test", "prod".
+	profile string
+	// envPrefix is the prefix of env vars which override the keys, empty disables it.
+	envPrefix string
+	// sets are the "key=value" overrides from args.
+	sets []string
+	// strict enables the strict mode of Unmarshal.
+	strict bool
+	// sources are added by WithSource, they are loaded after the sources in the configuration.
+	sources []Source
+	// watchDebounce is the delay of reloading after the watched files changed.
+	watchDebounce time.Duration
+	// redactPatterns match the secret-looking keys of Explain and Dump, nil uses DefaultRedactPatterns.
+	redactPatterns []*regexp.Regexp
 }
 
 // Option the function to apply configuration option
@@ -55,13 +73,61 @@ func WithIncludeFiles(paths ...string) Option {
 				panic(fmt.Errorf("attach config file %q error,%s", s, err))
 			}
 			o.includeFiles = append(o.includeFiles, s)
+			o.optionIncludeFiles = append(o.optionIncludeFiles, s)
 		}
 	}
 }
 
+// WithProfile sets the active profile, it overrides the env WOOCOO_PROFILE. With a profile such as "test",
+// the profile file "app.test.yaml" in the dir of the local file is merged over the local file and the include files,
+// and the env file ".env.test" is loaded after ".env" and ".env.local".
+func WithProfile(profile string) Option {
+	return func(o *options) {
+		o.profile = profile
+	}
+}
+
+// WithStrict enables the strict mode of Configuration.Unmarshal, which is:
+//   - applies the `default` tags of the zero fields before decoding.
+//   - errors if any key does not match a field, such as a misspelled key.
+//   - validates the result by the `validate` tags.
+//
+// The errors report the full configuration path.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
 // WithGlobal indicate weather use as global configuration
 func WithGlobal(g bool) Option {
 	return func(o *options) {
 		o.global = g
 	}
 }
+
+// WithWatchDebounce sets the delay of reloading after the watched files changed, the changes in the delay are merged
+// into one reloading. Default is 100ms.
+func WithWatchDebounce(d time.Duration) Option {
+	return func(o *options) {
+		o.watchDebounce = d
+	}
+}
+
+// WithSource adds the sources which are loaded after the local files and the sources declared in the configuration,
+// the latter added source overrides the former.
+func WithSource(sources ...Source) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, sources...)
+	}
+}
+
+// WithRedactPatterns sets the patterns of the secret-looking keys whose values are masked by Configuration.Explain
+// and Configuration.Dump, the patterns are case-insensitive regular expressions matching the last part of the key.
+// Default is DefaultRedactPatterns. It panics if any pattern is invalid.
+func WithRedactPatterns(patterns ...string) Option {
+	res := compileRedactPatterns(patterns)
+	return func(o *options) {
+		o.redactPatterns = res
+	}
+}
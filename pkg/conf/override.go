@@ -0,0 +1,198 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	argSet        = "--set"
+	argConfigFile = "--config-file"
+)
+
+// WithEnvPrefix enables overriding any key by the env vars with the prefix. The rest of the env name is split by
+// "_" and matched with the existing keys case-insensitively, a numeric part is the index of a slice. For example,
+// with prefix "APP":
+//
+//	APP_WEB_SERVER_ADDR=:8080                      -> web.server.addr
+//	APP_WEB_ENGINE_ROUTERGROUPS_0_DEFAULT_BASEPATH -> web.engine.routerGroups[0].default.basePath
+//
+// The parts not matching an existing key are used as lower case keys. The value is kept as a string, and converted
+// by the decode hooks of Unmarshal.
+//
+// The precedence of the configuration is: local file < include files < profile file < sources < env < args.
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = strings.TrimSuffix(prefix, "_") + "_"
+	}
+}
+
+// WithArgs parses the command-line args, usually os.Args[1:], the other args are ignored:
+//
+//	--set key=value     overrides the key, it can be repeated. The index of a slice is like "routerGroups[0]".
+//	--config-file path  uses the file as the local file, use it after WithBaseDir.
+//
+// Both "--set key=value" and "--set=key=value" are supported.
+func WithArgs(args []string) Option {
+	return func(o *options) {
+		for i := 0; i < len(args); i++ {
+			name, value, ok := strings.Cut(args[i], "=")
+			if name != argSet && name != argConfigFile {
+				continue
+			}
+			if !ok {
+				if i+1 >= len(args) {
+					panic(fmt.Errorf("conf: missing value of arg %s", name))
+				}
+				i++
+				value = args[i]
+			}
+			switch name {
+			case argSet:
+				o.sets = append(o.sets, value)
+			case argConfigFile:
+				WithLocalPath(value)(o)
+			}
+		}
+	}
+}
+
+// applyOverrides sets the values from the env vars and the args over the loaded configuration.
+func (c *Configuration) applyOverrides() error {
+	if c.opts.envPrefix == "" && len(c.opts.sets) == 0 {
+		return nil
+	}
+	raw := c.Parser().k.Raw()
+	if c.opts.envPrefix != "" {
+		var names []string
+		values := make(map[string]string)
+		for _, kv := range os.Environ() {
+			name, value, _ := strings.Cut(kv, "=")
+			if rest, ok := strings.CutPrefix(name, c.opts.envPrefix); ok && rest != "" {
+				names = append(names, rest)
+				values[rest] = value
+			}
+		}
+		// sort for a stable result when the env vars override the same key.
+		sort.Strings(names)
+		for _, name := range names {
+			path := resolveEnvPath(raw, strings.Split(name, "_"))
+			if err := setOverride(raw, path, values[name]); err != nil {
+				return fmt.Errorf("conf: env %s%s: %w", c.opts.envPrefix, name, err)
+			}
+			c.provenance = append(c.provenance, Provenance{
+				Key: formatKeyPath(path), Origin: OriginEnv, Location: c.opts.envPrefix + name, Value: values[name],
+			})
+		}
+	}
+	for _, set := range c.opts.sets {
+		key, value, ok := strings.Cut(set, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("conf: invalid arg %s %q, should be key=value", argSet, set)
+		}
+		path := splitKeyPath(key)
+		if err := setOverride(raw, path, value); err != nil {
+			return fmt.Errorf("conf: arg %s %s: %w", argSet, key, err)
+		}
+		c.provenance = append(c.provenance, Provenance{
+			Key: formatKeyPath(path), Origin: OriginFlag, Location: argSet + " " + key, Value: value,
+		})
+	}
+	return c.Parser().loadRaw(raw)
+}
+
+// splitKeyPath splits a key like "a.b[0].c" into ["a", "b", "0", "c"].
+func splitKeyPath(key string) []string {
+	key = strings.NewReplacer("[", KeyDelimiter, "]", "").Replace(key)
+	parts := strings.Split(key, KeyDelimiter)
+	res := parts[:0]
+	for _, p := range parts {
+		if p != "" {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// resolveEnvPath matches the upper case parts of an env name with the keys of node, the shortest joined parts
+// matching a key wins, such as "ROUTER_GROUPS" and "ROUTERGROUPS" both match "routerGroups".
+func resolveEnvPath(node any, parts []string) []string {
+	if len(parts) == 0 {
+		return nil
+	}
+	switch v := node.(type) {
+	case []any:
+		if idx, err := strconv.Atoi(parts[0]); err == nil && idx >= 0 && idx < len(v) {
+			return append([]string{parts[0]}, resolveEnvPath(v[idx], parts[1:])...)
+		}
+	case map[string]any:
+		for j := 1; j <= len(parts); j++ {
+			want := strings.ToLower(strings.Join(parts[:j], ""))
+			for key, child := range v {
+				if normalizeKey(key) == want {
+					return append([]string{key}, resolveEnvPath(child, parts[j:])...)
+				}
+			}
+		}
+	}
+	res := make([]string, len(parts))
+	for i, p := range parts {
+		res[i] = strings.ToLower(p)
+	}
+	return res
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
+
+// setOverride sets value into the map by path, a numeric part is the index of a slice node.
+func setOverride(raw map[string]any, path []string, value any) error {
+	if len(path) == 0 {
+		return fmt.Errorf("empty key")
+	}
+	_, err := setPathValue(raw, path, value)
+	return err
+}
+
+// setPathValue sets value into node by path and returns the new node. The index of a slice can be the length of
+// the slice to append an item.
+func setPathValue(node any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	key := path[0]
+	idx, err := strconv.Atoi(key)
+	isIndex := err == nil && idx >= 0
+	s, ok := node.([]any)
+	if !ok && node == nil && isIndex {
+		s, ok = []any{}, true
+	}
+	if ok {
+		if !isIndex || idx > len(s) {
+			return nil, fmt.Errorf("invalid index %q of slice with length %d", key, len(s))
+		}
+		if idx == len(s) {
+			s = append(s, nil)
+		}
+		v, err := setPathValue(s[idx], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		s[idx] = v
+		return s, nil
+	}
+	m, ok := node.(map[string]any)
+	if !ok {
+		m = make(map[string]any)
+	}
+	v, err := setPathValue(m[key], path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	m[key] = v
+	return m, nil
+}
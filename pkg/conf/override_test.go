@@ -0,0 +1,80 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfiguration_Overrides(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+web:
+  server:
+    addr: :8080
+    tls: true
+  engine:
+    routerGroups:
+      - default:
+          basePath: /
+cache:
+  ttl: 1m
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.test.yaml"), []byte("cache:\n  ttl: 2m\n"), 0600))
+	other := filepath.Join(dir, "other.yaml")
+	require.NoError(t, os.WriteFile(other, []byte("appName: other\n"), 0600))
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("APP_WEB_SERVER_ADDR", ":9090")
+		t.Setenv("APP_WEB_SERVER_TLS", "false")
+		t.Setenv("APP_WEB_ENGINE_ROUTER_GROUPS_0_DEFAULT_BASEPATH", "/api")
+		t.Setenv("APP_CACHE_TTL", "3m")
+		t.Setenv("APP_CACHE_NEW_KEY", "new")
+		cnf := New(WithBaseDir(dir), WithLocalPath(file), WithProfile("test"), WithEnvPrefix("APP")).Load()
+		assert.Equal(t, ":9090", cnf.String("web.server.addr"))
+		assert.False(t, cnf.Bool("web.server.tls"))
+		assert.Equal(t, time.Minute*3, cnf.Duration("cache.ttl"))
+		assert.Equal(t, "new", cnf.String("cache.new.key"))
+		var v struct {
+			TLS bool `json:"tls"`
+		}
+		require.NoError(t, cnf.Sub("web.server").Unmarshal(&v))
+		assert.False(t, v.TLS)
+		cnf.Each("web.engine.routerGroups", func(root string, sub *Configuration) {
+			assert.Equal(t, "default", root)
+			assert.Equal(t, "/api", sub.String("basePath"))
+		})
+	})
+	t.Run("args", func(t *testing.T) {
+		t.Setenv("APP_WEB_SERVER_ADDR", ":9090")
+		cnf := New(WithBaseDir(dir), WithLocalPath(file), WithEnvPrefix("APP"), WithArgs([]string{
+			"-v", "--set", "web.server.addr=:7070", "--set=web.engine.routerGroups[1].auth.basePath=/auth",
+			"--set", "cache.tags[0]=a",
+		})).Load()
+		assert.Equal(t, ":7070", cnf.String("web.server.addr"))
+		assert.Equal(t, []string{"a"}, cnf.StringSlice("cache.tags"))
+		var groups []string
+		cnf.Each("web.engine.routerGroups", func(root string, sub *Configuration) {
+			groups = append(groups, root+":"+sub.String("basePath"))
+		})
+		assert.Equal(t, []string{"default:/", "auth:/auth"}, groups)
+	})
+	t.Run("config file", func(t *testing.T) {
+		cnf := New(WithBaseDir(dir), WithArgs([]string{"--config-file", other})).Load()
+		assert.Equal(t, "other", cnf.AppName())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cnf := New(WithBaseDir(dir), WithLocalPath(file), WithArgs([]string{"--set", "web.engine.routerGroups[3]=x"}))
+		assert.ErrorContains(t, cnf.loadInternal(), "invalid index")
+		cnf = New(WithBaseDir(dir), WithLocalPath(file), WithArgs([]string{"--set", "novalue"}))
+		assert.ErrorContains(t, cnf.loadInternal(), "should be key=value")
+		assert.Panics(t, func() {
+			New(WithArgs([]string{"--set"}))
+		})
+	})
+}
@@ -27,9 +27,12 @@ func NewParser() *Parser {
 	return NewParserFromOperator(k)
 }
 
-// NewParserFromFile creates a new Parser by reading the given file.
+// NewParserFromFile creates a new Parser by reading the given file, the format is picked by the file extension:
+// ".yaml", ".yml", ".json", ".toml", ".env" or the registered formats, YAML is used for others.
 func NewParserFromFile(fileName string) (*Parser, error) {
-	return NewParserFromProvider(file.Provider(fileName))
+	p := NewParser()
+	err := p.k.Load(file.Provider(fileName), parserByPath(fileName))
+	return p, err
 }
 
 // NewParserFromBuffer creates a new Parser by reading the given yaml buffer.
@@ -137,13 +140,14 @@ func (l *Parser) IsSet(key string) bool {
 	return l.k.Exists(key)
 }
 
-// LoadFileWithEnv loads the given file and env, and merges it into the config.
+// LoadFileWithEnv loads the given file and env, and merges it into the config. The format is picked by the file
+// extension like NewParserFromFile.
 func (l *Parser) LoadFileWithEnv(path string) error {
 	provider, err := fsProviderWithEnv(path)
 	if err != nil {
 		return err
 	}
-	return l.k.Load(provider, yaml.Parser())
+	return l.k.Load(provider, parserByPath(path))
 }
 
 // LoadProviderWithEnv loads the given provider and env, and merges it into the config.
@@ -165,6 +169,16 @@ func (l *Parser) MergeStringMap(cfg map[string]any) error {
 	return l.k.Merge(toMerge)
 }
 
+// loadRaw replaces the config by the nested map, the keys of the map are not split by the delimiter.
+func (l *Parser) loadRaw(raw map[string]any) error {
+	k := koanf.NewWithConf(koanf.Conf{Delim: KeyDelimiter, StrictMerge: false})
+	if err := k.Load(confmap.Provider(raw, ""), nil); err != nil {
+		return err
+	}
+	l.k = k
+	return nil
+}
+
 // Sub returns new Parser instance representing a sub-config of this instance.
 // It returns an error is the sub-config is not a map (use Get()) or if none exists.
 func (l *Parser) Sub(key string) (*Parser, error) {
@@ -190,7 +204,7 @@ func (l *Parser) ToStringMap() map[string]any {
 }
 
 // ToBytes takes a Parser implementation and marshals the config map into bytes,
-// for example, to TOML or JSON bytes.
+// for example, to TOML or JSON bytes. The parsers of the built-in formats can be got by GetFormat.
 func (l *Parser) ToBytes(p koanf.Parser) ([]byte, error) {
 	return l.k.Marshal(p)
 }
@@ -214,6 +228,8 @@ func decoderConfig(result any) *mapstructure.DecoderConfig {
 			expandNilStructPointers(),
 			textUnmarshalHookFunc(),
 			mapstructure.StringToTimeDurationHookFunc(),
+			stringToLocationHookFunc(),
+			stringToURLHookFunc(),
 			mapstructure.StringToSliceHookFunc(","),
 		),
 	}
@@ -0,0 +1,249 @@
+package conf
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// SecretKeyEnv is the env name of the key used by the default "aes-gcm" encryptor.
+	SecretKeyEnv = "WOOCOO_SECRET_KEY"
+	// EncryptorAESGCM is the name of the AES-GCM encryptor.
+	EncryptorAESGCM = "aes-gcm"
+)
+
+var (
+	// secretRegexp matches the secret reference like ${secret:file:/run/secrets/x}.
+	secretRegexp = regexp.MustCompile(`\$\{secret:([\w-]+):([^}]*)}`)
+	// encRegexp matches the encrypted value like ENC(aes-gcm:ciphertext).
+	encRegexp = regexp.MustCompile(`ENC\(([\w-]+):([^)]*)\)`)
+
+	secretMu        sync.RWMutex
+	encryptors      = map[string]Encryptor{EncryptorAESGCM: &AESEncryptor{}}
+	secretResolvers = map[string]SecretResolver{
+		"file": resolveFileSecret,
+		"env":  resolveEnvSecret,
+	}
+)
+
+type (
+	// Encryptor encrypts and decrypts the values in configuration, the encrypted value is written as
+	// ENC(name:ciphertext) where name is the registered name of the Encryptor.
+	Encryptor interface {
+		Encrypt(plaintext string) (string, error)
+		Decrypt(ciphertext string) (string, error)
+	}
+	// SecretResolver returns the secret by the reference, the secret is written as ${secret:scheme:ref}.
+	SecretResolver func(ref string) (string, error)
+)
+
+// RegisterEncryptor registers an Encryptor by name, it overrides the registered one with the same name.
+func RegisterEncryptor(name string, e Encryptor) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	encryptors[name] = e
+}
+
+// GetEncryptor returns the Encryptor by name.
+func GetEncryptor(name string) (Encryptor, bool) {
+	secretMu.RLock()
+	defer secretMu.RUnlock()
+	e, ok := encryptors[name]
+	return e, ok
+}
+
+// RegisterSecretResolver registers a SecretResolver by scheme. The built-in schemes are:
+//
+//	${secret:file:/run/secrets/x} the content of the file, the trailing line break is trimmed.
+//	${secret:env:NAME}            the value of the env, error if the env is not set.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	secretResolvers[scheme] = r
+}
+
+func getSecretResolver(scheme string) (SecretResolver, bool) {
+	secretMu.RLock()
+	defer secretMu.RUnlock()
+	r, ok := secretResolvers[scheme]
+	return r, ok
+}
+
+func resolveFileSecret(ref string) (string, error) {
+	bs, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(bs), "\r\n"), nil
+}
+
+func resolveEnvSecret(ref string) (string, error) {
+	v, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("env %s is not set", ref)
+	}
+	return v, nil
+}
+
+// ResolveSecret replaces the secret references and the encrypted values in s.
+func ResolveSecret(s string) (string, error) {
+	if !strings.Contains(s, "${secret:") && !strings.Contains(s, "ENC(") {
+		return s, nil
+	}
+	var errs []error
+	s = secretRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		sm := secretRegexp.FindStringSubmatch(m)
+		r, ok := getSecretResolver(sm[1])
+		if !ok {
+			errs = append(errs, fmt.Errorf("secret scheme %q is not registered", sm[1]))
+			return m
+		}
+		v, err := r(sm[2])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resolve secret %s: %w", m, err))
+			return m
+		}
+		return v
+	})
+	s = encRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		sm := encRegexp.FindStringSubmatch(m)
+		e, ok := GetEncryptor(sm[1])
+		if !ok {
+			errs = append(errs, fmt.Errorf("encryptor %q is not registered", sm[1]))
+			return m
+		}
+		v, err := e.Decrypt(sm[2])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("decrypt value of %s: %w", sm[1], err))
+			return m
+		}
+		return v
+	})
+	return s, errors.Join(errs...)
+}
+
+// resolveSecrets resolves the secrets in the values of all keys.
+func (c *Configuration) resolveSecrets() error {
+	raw := c.Parser().k.Raw()
+	changed, err := resolveSecretValue(raw, "", func(key string) {
+		c.provenance = append(c.provenance, Provenance{Key: key, Origin: OriginSecret})
+	})
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	return c.Parser().loadRaw(raw)
+}
+
+// resolveSecretValue resolves the strings in the maps and slices in place, and reports whether any value changed.
+// The resolved callback is called with the path of every changed value.
+func resolveSecretValue(v any, path string, resolved func(key string)) (changed bool, err error) {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			kp := joinPath(path, k)
+			if s, ok := item.(string); ok {
+				rs, err := ResolveSecret(s)
+				if err != nil {
+					return false, fmt.Errorf("conf: %s: %w", kp, err)
+				}
+				if rs != s {
+					val[k], changed = rs, true
+					resolved(kp)
+				}
+				continue
+			}
+			c, err := resolveSecretValue(item, kp, resolved)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+	case []any:
+		for i, item := range val {
+			ip := fmt.Sprintf("%s[%d]", path, i)
+			if s, ok := item.(string); ok {
+				rs, err := ResolveSecret(s)
+				if err != nil {
+					return false, fmt.Errorf("conf: %s: %w", ip, err)
+				}
+				if rs != s {
+					val[i], changed = rs, true
+					resolved(ip)
+				}
+				continue
+			}
+			c, err := resolveSecretValue(item, ip, resolved)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+	}
+	return changed, nil
+}
+
+// AESEncryptor is the AES-GCM Encryptor, the ciphertext is base64 encoded with the nonce as prefix.
+// The key is read from the env KeyEnv at every call, its length should be 16, 24 or 32.
+type AESEncryptor struct {
+	// KeyEnv is the env name of the key, default is SecretKeyEnv.
+	KeyEnv string
+}
+
+func (a *AESEncryptor) gcm() (cipher.AEAD, error) {
+	env := a.KeyEnv
+	if env == "" {
+		env = SecretKeyEnv
+	}
+	block, err := aes.NewCipher([]byte(os.Getenv(env)))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt implements Encryptor.
+func (a *AESEncryptor) Encrypt(text string) (string, error) {
+	gcm, err := a.gcm()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	ciphertext := gcm.Seal(nonce, nonce, []byte(text), nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt implements Encryptor.
+func (a *AESEncryptor) Decrypt(text string) (string, error) {
+	gcm, err := a.gcm()
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return "", err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return "", fmt.Errorf("malformed ciphertext")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
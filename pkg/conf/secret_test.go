@@ -0,0 +1,86 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reverseEncryptor struct{}
+
+func (reverseEncryptor) Encrypt(s string) (string, error) {
+	return reverse(s), nil
+}
+
+func (reverseEncryptor) Decrypt(s string) (string, error) {
+	return reverse(s), nil
+}
+
+func reverse(s string) string {
+	rs := []rune(s)
+	for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+		rs[i], rs[j] = rs[j], rs[i]
+	}
+	return string(rs)
+}
+
+func TestConfiguration_ResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "redis-password")
+	require.NoError(t, os.WriteFile(secretFile, []byte("filepwd\n"), 0600))
+	t.Setenv("TEST_JWT_KEY", "jwtkey")
+	t.Setenv(SecretKeyEnv, "7d9f4e8b12c6a3f5e1b0d8c2a5f7e891")
+	enc, err := (&AESEncryptor{}).Encrypt("clientsecret")
+	require.NoError(t, err)
+	RegisterEncryptor("reverse", reverseEncryptor{})
+
+	t.Run("resolve", func(t *testing.T) {
+		cnf := NewFromBytes([]byte(`
+redis:
+  password: ${secret:file:` + secretFile + `}
+jwt:
+  signingKey: ${secret:env:TEST_JWT_KEY}
+oauth2:
+  clients:
+    - clientSecret: ENC(aes-gcm:` + enc + `)
+db:
+  dsn: root:ENC(reverse:dwp)@tcp(127.0.0.1:3306)
+plain: value
+`)).Load()
+		assert.Equal(t, "filepwd", cnf.String("redis.password"))
+		assert.Equal(t, "jwtkey", cnf.String("jwt.signingKey"))
+		assert.Equal(t, "clientsecret", cnf.Sub("oauth2").ParserOperator().Slices("clients")[0].String("clientSecret"))
+		assert.Equal(t, "root:pwd@tcp(127.0.0.1:3306)", cnf.String("db.dsn"))
+		assert.Equal(t, "value", cnf.String("plain"))
+	})
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			value string
+			err   string
+		}{
+			{value: "${secret:env:TEST_NOT_SET}", err: "conf: key: resolve secret ${secret:env:TEST_NOT_SET}: env TEST_NOT_SET is not set"},
+			{value: "${secret:vault:x}", err: `secret scheme "vault" is not registered`},
+			{value: "ENC(unknown:x)", err: `encryptor "unknown" is not registered`},
+			{value: "ENC(aes-gcm:x)", err: "decrypt value of aes-gcm"},
+			{value: "${secret:file:" + filepath.Join(dir, "none") + "}", err: "no such file"},
+		}
+		for _, tt := range tests {
+			cnf := NewFromBytes([]byte("key: " + tt.value))
+			err := cnf.loadInternal()
+			require.Error(t, err, tt.value)
+			assert.True(t, strings.Contains(err.Error(), tt.err), err.Error())
+		}
+	})
+	t.Run("slice", func(t *testing.T) {
+		cnf := NewFromBytes([]byte("keys:\n  - ${secret:env:TEST_JWT_KEY}\n  - ENC(reverse:cba)\n"))
+		require.NoError(t, cnf.loadInternal())
+		assert.Equal(t, []string{"jwtkey", "abc"}, cnf.StringSlice("keys"))
+		cnf = NewFromBytes([]byte("keys:\n  - ENC(reverse)\n"))
+		require.NoError(t, cnf.loadInternal())
+		assert.Equal(t, []string{"ENC(reverse)"}, cnf.StringSlice("keys"))
+	})
+}
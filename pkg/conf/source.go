@@ -0,0 +1,268 @@
+package conf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	sourcesKey = "sources"
+
+	defaultSourceTimeout  = 10 * time.Second
+	defaultSourceInterval = 30 * time.Second
+)
+
+var (
+	// ErrSourceNotFound is returned when the scheme of a source is not registered.
+	ErrSourceNotFound = errors.New("conf: source scheme is not registered")
+
+	sourceMu        sync.RWMutex
+	sourceFactories = map[string]SourceFactory{
+		"http":  NewHTTPSource,
+		"https": NewHTTPSource,
+	}
+)
+
+type (
+	// Source provides configuration values from a location other than the local files, such as a configuration
+	// service. The values of sources are merged on top of the local file and the include files, the later source
+	// in the `sources` list overrides the former, and the sources added by WithSource override all of them.
+	Source interface {
+		// Load returns the current values of the source.
+		Load(ctx context.Context) (map[string]any, error)
+		// Watch blocks until ctx is done, and calls onChange after the values of the source changed.
+		// A source that does not support watching can return nil immediately.
+		Watch(ctx context.Context, onChange func()) error
+	}
+	// SourceFactory creates a Source by an item of `sources` in the configuration, such as:
+	//
+	//	sources:
+	//	- scheme: http
+	//	  url: http://127.0.0.1:8080/config/app.json
+	SourceFactory func(cnf *Configuration) (Source, error)
+)
+
+// RegisterSource registers a SourceFactory by scheme, it overrides the registered factory with the same scheme.
+func RegisterSource(scheme string, factory SourceFactory) {
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	sourceFactories[scheme] = factory
+}
+
+// GetSource returns the SourceFactory by scheme.
+func GetSource(scheme string) (SourceFactory, bool) {
+	sourceMu.RLock()
+	defer sourceMu.RUnlock()
+	f, ok := sourceFactories[scheme]
+	return f, ok
+}
+
+// sourceItem is the common settings of an item of `sources`.
+type sourceItem struct {
+	Scheme string `json:"scheme"`
+	// Optional indicates the loading error of the source is logged and ignored.
+	Optional bool `json:"optional"`
+	// Timeout is the timeout of loading, default is 10s.
+	Timeout time.Duration `json:"timeout"`
+}
+
+// sourceItemKeys are the keys of sourceItem, they are removed from the configuration passed to the SourceFactory.
+var sourceItemKeys = []string{"scheme", "optional", "timeout"}
+
+// loadedSource is a Source with its settings.
+type loadedSource struct {
+	Source
+	sourceItem
+}
+
+// buildSources creates the sources declared in the configuration and appends the sources added by options.
+//
+// The common settings of an item are decoded leniently, and the factory gets the item without them, so that the
+// factory can decode its own settings strictly. The sub Configuration of an item has the path such as "sources.0".
+func (c *Configuration) buildSources() ([]*loadedSource, error) {
+	var res []*loadedSource
+	for i, op := range c.ParserOperator().Slices(sourcesKey) {
+		path := joinPath(c.prefix, sourcesKey+KeyDelimiter+strconv.Itoa(i))
+		item := sourceItem{Timeout: defaultSourceTimeout}
+		if err := c.CutFromOperator(op).Parser().Unmarshal("", &item); err != nil {
+			return nil, fmt.Errorf("conf: unmarshal %s: %w", path, err)
+		}
+		factory, ok := GetSource(item.Scheme)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q of %s", ErrSourceNotFound, item.Scheme, path)
+		}
+		driver := op.Copy()
+		for _, key := range sourceItemKeys {
+			driver.Delete(key)
+		}
+		sub := c.CutFromOperator(driver)
+		sub.prefix = path
+		src, err := factory(sub)
+		if err != nil {
+			return nil, fmt.Errorf("conf: create source %q of %s: %w", item.Scheme, path, err)
+		}
+		res = append(res, &loadedSource{Source: src, sourceItem: item})
+	}
+	for _, src := range c.opts.sources {
+		res = append(res, &loadedSource{Source: src, sourceItem: sourceItem{Timeout: defaultSourceTimeout}})
+	}
+	return res, nil
+}
+
+// loadSources loads the values of sources and merges them into the configuration in order.
+func (c *Configuration) loadSources() error {
+	sources, err := c.buildSources()
+	if err != nil {
+		return err
+	}
+	for _, src := range sources {
+		ctx, cancel := context.WithTimeout(context.Background(), src.Timeout)
+		values, err := src.Load(ctx)
+		cancel()
+		if err != nil {
+			if src.Optional {
+				log.Printf("conf: load optional source %q error: %v", src.Scheme, err)
+				continue
+			}
+			return fmt.Errorf("conf: load source %q: %w", src.Scheme, err)
+		}
+		sp := NewParserFromStringMap(values)
+		location := src.Scheme
+		if location == "" {
+			// the source added by WithSource.
+			location = fmt.Sprintf("%T", src.Source)
+		}
+		c.track(OriginSource, location, sp.k)
+		if err = c.Parser().k.Merge(sp.k); err != nil {
+			return err
+		}
+	}
+	c.sources = sources
+	return nil
+}
+
+// HTTPSource loads the configuration from a http(s) url which responds a JSON object, and watches the changes
+// by polling. Configuration example:
+//
+//	sources:
+//	- scheme: http
+//	  url: http://127.0.0.1:8080/config/app.json
+//	  interval: 30s # polling interval, 0 disables watching.
+//	  headers:
+//	    Authorization: Bearer xxx
+type HTTPSource struct {
+	URL      string            `json:"url"`
+	Headers  map[string]string `json:"headers"`
+	Interval time.Duration     `json:"interval"`
+
+	Client *http.Client `json:"-"`
+
+	mu   sync.Mutex
+	etag string
+	hash uint64
+}
+
+// NewHTTPSource creates a HTTPSource by configuration, it is the SourceFactory of scheme http and https.
+func NewHTTPSource(cnf *Configuration) (Source, error) {
+	s := &HTTPSource{
+		Interval: defaultSourceInterval,
+		Client:   http.DefaultClient,
+	}
+	if err := cnf.Unmarshal(s); err != nil {
+		return nil, err
+	}
+	if s.URL == "" {
+		return nil, errors.New("conf: http source url is empty")
+	}
+	return s, nil
+}
+
+// Load requests the url and decodes the JSON response body.
+func (s *HTTPSource) Load(ctx context.Context) (map[string]any, error) {
+	body, _, err := s.fetch(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]any)
+	if err = json.Unmarshal(body, &values); err != nil {
+		return nil, fmt.Errorf("conf: decode response of %s: %w", s.URL, err)
+	}
+	return values, nil
+}
+
+// Watch polls the url by the interval, the change is detected by ETag or the hash of the response body.
+func (s *HTTPSource) Watch(ctx context.Context, onChange func()) error {
+	if s.Interval <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			_, changed, err := s.fetch(ctx, true)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				log.Printf("conf: watch http source %s error: %v", s.URL, err)
+				continue
+			}
+			if changed {
+				onChange()
+			}
+		}
+	}
+}
+
+// fetch requests the url and reports whether the response changed since the last fetch.
+func (s *HTTPSource) fetch(ctx context.Context, conditional bool) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	s.mu.Lock()
+	etag, last := s.etag, s.hash
+	s.mu.Unlock()
+	if conditional && etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("conf: request %s: unexpected status %s", s.URL, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	h := fnv.New64a()
+	h.Write(bytes.TrimSpace(body))
+	sum := h.Sum64()
+	s.mu.Lock()
+	s.etag, s.hash = resp.Header.Get("ETag"), sum
+	s.mu.Unlock()
+	return body, sum != last, nil
+}
@@ -0,0 +1,141 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSource struct {
+	values map[string]any
+	err    error
+}
+
+func (m *mockSource) Load(context.Context) (map[string]any, error) {
+	return m.values, m.err
+}
+
+func (m *mockSource) Watch(context.Context, func()) error {
+	return nil
+}
+
+func newConfigServer(t *testing.T, body *atomic.Value) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("Authorization"))
+		b := body.Load().(string)
+		etag := `"` + b + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(b))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestConfiguration_Sources(t *testing.T) {
+	var body atomic.Value
+	body.Store(`{"web":{"addr":":9090"},"cache":{"ttl":"2m"}}`)
+	srv := newConfigServer(t, &body)
+	local := `
+web:
+  addr: :8080
+  tls: false
+cache:
+  ttl: 1m
+sources:
+  - scheme: http
+    url: ` + srv.URL + `
+    headers:
+      Authorization: token
+`
+	t.Run("merge", func(t *testing.T) {
+		cnf := NewFromBytes([]byte(local)).Load()
+		assert.Equal(t, ":9090", cnf.String("web.addr"))
+		assert.False(t, cnf.Bool("web.tls"))
+		assert.Equal(t, time.Minute*2, cnf.Duration("cache.ttl"))
+	})
+	t.Run("precedence", func(t *testing.T) {
+		cnf := NewFromBytes([]byte(local), WithSource(&mockSource{
+			values: map[string]any{"web": map[string]any{"addr": ":7070"}},
+		})).Load()
+		assert.Equal(t, ":7070", cnf.String("web.addr"))
+		assert.Equal(t, time.Minute*2, cnf.Duration("cache.ttl"))
+	})
+	t.Run("unknown scheme", func(t *testing.T) {
+		cnf := NewFromBytes([]byte("sources:\n  - scheme: unknown\n"))
+		assert.ErrorIs(t, cnf.loadInternal(), ErrSourceNotFound)
+	})
+	t.Run("optional", func(t *testing.T) {
+		cnf := NewFromBytes([]byte("a: 1\nsources:\n  - scheme: mock\n    optional: true\n"))
+		RegisterSource("mock", func(*Configuration) (Source, error) {
+			return &mockSource{err: errors.New("unavailable")}, nil
+		})
+		require.NoError(t, cnf.loadInternal())
+		assert.Equal(t, 1, cnf.Int("a"))
+		cnf = NewFromBytes([]byte("a: 1\nsources:\n  - scheme: mock\n"))
+		assert.ErrorContains(t, cnf.loadInternal(), "unavailable")
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cnf := NewFromBytes([]byte("sources:\n  - scheme: http\n"))
+		assert.ErrorContains(t, cnf.loadInternal(), "url is empty")
+	})
+	t.Run("strict", func(t *testing.T) {
+		cnf := NewFromBytes([]byte(local+"    optional: true\n    timeout: 5s\n"), WithStrict()).Load()
+		assert.Equal(t, ":9090", cnf.String("web.addr"))
+
+		cnf = NewFromBytes([]byte("sources:\n  - scheme: env\n  - scheme: http\n    ulr: "+srv.URL+"\n"), WithStrict())
+		RegisterSource("env", func(*Configuration) (Source, error) {
+			return &mockSource{}, nil
+		})
+		assert.EqualError(t, cnf.loadInternal(), `conf: create source "http" of sources.1: conf: unknown keys: sources.1.ulr`)
+	})
+}
+
+func TestConfiguration_WatchSource(t *testing.T) {
+	var body atomic.Value
+	body.Store(`{"web":{"addr":":9090"}}`)
+	srv := newConfigServer(t, &body)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+web:
+  addr: :8080
+cache:
+  ttl: 1m
+sources:
+  - scheme: http
+    url: `+srv.URL+`
+    interval: 50ms
+    headers:
+      Authorization: token
+`), 0600))
+	cnf := New(WithBaseDir(dir), WithLocalPath(file), WithWatchDebounce(time.Millisecond*10)).Load()
+	assert.Equal(t, ":9090", cnf.String("web.addr"))
+	ch := make(chan *Configuration, 10)
+	cnf.Watch("web", func(sub *Configuration) {
+		ch <- sub
+	})
+	defer cnf.StopWatch()
+
+	body.Store(`{"web":{"addr":":7070"}}`)
+	select {
+	case sub := <-ch:
+		assert.Equal(t, ":7070", sub.String("addr"))
+	case <-time.After(time.Second * 3):
+		require.Fail(t, "source change is not notified")
+	}
+	assert.Equal(t, time.Minute, cnf.Duration("cache.ttl"))
+}
@@ -0,0 +1,195 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/mitchellh/hashstructure/v2"
+)
+
+// ErrNoLocalFile is returned when reload a Configuration which is not loaded from local files.
+var ErrNoLocalFile = errors.New("conf: configuration has no local file to reload")
+
+type (
+	// ChangeFunc is called with the old and new Configuration of the subscribed path after the value changed.
+	// old is nil if the path is added, new is nil if the path is removed.
+	ChangeFunc func(old, new *Configuration)
+
+	subscription struct {
+		path string
+		cb   ChangeFunc
+	}
+	// subscribers hold the subscriptions of a root Configuration.
+	subscribers struct {
+		mu   sync.RWMutex
+		subs []*subscription
+		// reloading serializes the reloading triggered by signals and file watching.
+		reloading sync.Mutex
+		// filesMu guards the include files and the sources of the root Configuration which are replaced by reloading
+		// and read by the file watcher.
+		filesMu sync.RWMutex
+	}
+)
+
+func newSubscribers() *subscribers {
+	return &subscribers{}
+}
+
+func (s *subscribers) add(sub *subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs = append(s.subs, sub)
+}
+
+func (s *subscribers) remove(sub *subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, v := range s.subs {
+		if v == sub {
+			s.subs = append(s.subs[:i:i], s.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *subscribers) list() []*subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make([]*subscription, len(s.subs))
+	copy(res, s.subs)
+	return res
+}
+
+// Subscribe registers cb to be called when the value of path changed after reloading. The path is relative to
+// the current Configuration, empty path means the current node. The path should be a map node, if you want to
+// observe a leaf value, subscribe its parent.
+//
+// Subscriptions are held by the root Configuration, and the callbacks are called in registration order. The returned
+// function cancels the subscription, the owner which lives shorter than the root Configuration should call it.
+func (c *Configuration) Subscribe(path string, cb ChangeFunc) (unsubscribe func()) {
+	root := c.Root()
+	if root.subs == nil {
+		root.subs = newSubscribers()
+	}
+	sub := &subscription{path: joinPath(c.prefix, path), cb: cb}
+	root.subs.add(sub)
+	subs := root.subs
+	return func() {
+		subs.remove(sub)
+	}
+}
+
+// Path returns the full path of the Configuration from the root, it is empty for the root.
+func (c *Configuration) Path() string {
+	return c.prefix
+}
+
+// TryReload reloads the configuration from the local file, the include files and the sources, and notifies the subscribers
+// whose subtree changed. If any error occurs, the current configuration will be kept.
+//
+// It always reloads the root Configuration, and the values set manually will be lost after reloading.
+func (c *Configuration) TryReload() (err error) {
+	root := c.Root()
+	if root.subs == nil {
+		root.subs = newSubscribers()
+	}
+	root.subs.reloading.Lock()
+	defer root.subs.reloading.Unlock()
+	if root.opts.localPath == "" && len(root.opts.includeFiles) == 0 && len(root.sources) == 0 {
+		return ErrNoLocalFile
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("conf: reload configuration: %v", r)
+		}
+	}()
+	tmp := &Configuration{
+		opts: root.opts,
+	}
+	tmp.parser.Store(NewParser())
+	// the include files declared in the file are discovered again, the ones from options are kept.
+	tmp.opts.includeFiles = slices.Clone(root.opts.optionIncludeFiles)
+	if err = tmp.loadInternal(); err != nil {
+		return err
+	}
+	old := root.CutFromOperator(root.Parser().k)
+	root.subs.filesMu.Lock()
+	root.opts.includeFiles = tmp.opts.includeFiles
+	root.sources = tmp.sources
+	root.subs.filesMu.Unlock()
+	root.provenance = tmp.provenance
+	root.parser.Store(tmp.Parser())
+	root.Development = root.Parser().k.Bool("development")
+	root.notify(old)
+	return nil
+}
+
+// notify calls the subscribers whose path value changed between the old and the current configuration.
+func (c *Configuration) notify(old *Configuration) {
+	if c.subs == nil {
+		return
+	}
+	for _, sub := range c.subs.list() {
+		if !changed(old, c, sub.path) {
+			continue
+		}
+		callChange(sub, subOrNil(old, sub.path), subOrNil(c, sub.path))
+	}
+}
+
+func callChange(sub *subscription, old, new *Configuration) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("conf: subscriber of path %q panic: %v", sub.path, r)
+		}
+	}()
+	sub.cb(old, new)
+}
+
+// changed reports whether the value of path differs between two configurations by comparing their hash.
+func changed(old, new *Configuration, path string) bool {
+	oh, err := hashValue(old, path)
+	if err != nil {
+		return true
+	}
+	nh, err := hashValue(new, path)
+	if err != nil {
+		return true
+	}
+	return oh != nh
+}
+
+func hashValue(cnf *Configuration, path string) (uint64, error) {
+	var v any
+	if path == "" {
+		v = cnf.Parser().k.Raw()
+	} else {
+		v = cnf.Parser().Get(path)
+	}
+	return hashstructure.Hash(v, hashstructure.FormatV2, nil)
+}
+
+// subOrNil returns the sub Configuration of path, or nil if the path not exists or is not a map.
+func subOrNil(cnf *Configuration, path string) *Configuration {
+	if path == "" {
+		return cnf
+	}
+	if !cnf.IsSet(path) {
+		return nil
+	}
+	p, err := cnf.Parser().Sub(path)
+	if err != nil {
+		return nil
+	}
+	nc := &Configuration{
+		opts:        cnf.opts,
+		prefix:      path,
+		Development: cnf.Development,
+	}
+	nc.parser.Store(p)
+	cnf.passRoot(nc)
+	return nc
+}
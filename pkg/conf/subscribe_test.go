@@ -0,0 +1,151 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfiguration_TryReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+appName: test
+web:
+  server:
+    addr: :8080
+cache:
+  ttl: 1m
+`), 0600))
+	cnf := New(WithBaseDir(dir), WithLocalPath(file)).Load()
+	var (
+		webOld, webNew *Configuration
+		cacheCalled    int
+		rootCalled     int
+	)
+	cnf.Sub("web").Subscribe("server", func(old, new *Configuration) {
+		webOld, webNew = old, new
+	})
+	cnf.Subscribe("cache", func(old, new *Configuration) {
+		cacheCalled++
+	})
+	cnf.Subscribe("", func(old, new *Configuration) {
+		rootCalled++
+		assert.Equal(t, "test", old.AppName())
+	})
+	assert.Equal(t, "web.server", cnf.Sub("web").Sub("server").Path())
+
+	t.Run("changed", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(`
+appName: test
+web:
+  server:
+    addr: :9090
+cache:
+  ttl: 1m
+`), 0600))
+		require.NoError(t, cnf.TryReload())
+		require.NotNil(t, webOld)
+		require.NotNil(t, webNew)
+		assert.Equal(t, ":8080", webOld.String("addr"))
+		assert.Equal(t, ":9090", webNew.String("addr"))
+		assert.Equal(t, "web.server", webNew.Path())
+		assert.Equal(t, ":9090", cnf.String("web.server.addr"))
+		assert.Equal(t, 0, cacheCalled)
+		assert.Equal(t, 1, rootCalled)
+	})
+	t.Run("removed", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(`
+appName: test
+web:
+  server:
+    addr: :9090
+`), 0600))
+		require.NoError(t, cnf.TryReload())
+		assert.Equal(t, 1, cacheCalled)
+		assert.False(t, cnf.IsSet("cache"))
+	})
+	t.Run("parse error keeps current", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(`appName: [`), 0600))
+		assert.Error(t, cnf.TryReload())
+		assert.Equal(t, ":9090", cnf.String("web.server.addr"))
+		assert.Equal(t, 2, rootCalled)
+	})
+	t.Run("no local file", func(t *testing.T) {
+		assert.ErrorIs(t, NewFromBytes([]byte(`a: 1`)).TryReload(), ErrNoLocalFile)
+	})
+	t.Run("panic subscriber", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(`appName: test2`), 0600))
+		cnf.Subscribe("appName", func(old, new *Configuration) {
+			panic("subscriber panic")
+		})
+		assert.NoError(t, cnf.TryReload())
+		assert.Equal(t, "test2", cnf.AppName())
+	})
+	t.Run("read while reloading", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				assert.Equal(t, "test2", cnf.AppName())
+			}
+		}()
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, cnf.TryReload())
+		}
+		<-done
+	})
+}
+
+func TestConfiguration_Subscribe(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	write := func(addr string) {
+		require.NoError(t, os.WriteFile(file, []byte("web:\n  server:\n    addr: "+addr+"\n"), 0600))
+	}
+	write(":8080")
+	cnf := New(WithBaseDir(dir), WithLocalPath(file)).Load()
+	var called, otherCalled int
+	unsubscribe := cnf.Sub("web").Subscribe("server", func(_, _ *Configuration) {
+		called++
+	})
+	cnf.Subscribe("web", func(_, _ *Configuration) {
+		otherCalled++
+	})
+	write(":9090")
+	require.NoError(t, cnf.TryReload())
+	assert.Equal(t, 1, called)
+
+	unsubscribe()
+	unsubscribe()
+	write(":9091")
+	require.NoError(t, cnf.TryReload())
+	assert.Equal(t, 1, called)
+	assert.Equal(t, 2, otherCalled, "other subscribers are kept")
+}
+
+func TestConfiguration_TryReload_IncludeFiles(t *testing.T) {
+	dir := t.TempDir()
+	file, include, declared := filepath.Join(dir, "app.yaml"), filepath.Join(dir, "include.yaml"),
+		filepath.Join(dir, "declared.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("a: 1\n"), 0600))
+	require.NoError(t, os.WriteFile(include, []byte("b: 2\n"), 0600))
+	require.NoError(t, os.WriteFile(declared, []byte("c: 3\n"), 0600))
+	cnf := New(WithBaseDir(dir), WithLocalPath(file), WithIncludeFiles(include)).Load()
+	assert.Equal(t, map[string]any{"a": 1, "b": 2}, cnf.AllSettings())
+
+	require.NoError(t, cnf.TryReload())
+	assert.Equal(t, map[string]any{"a": 1, "b": 2}, cnf.AllSettings(), "the include files of options should be kept")
+
+	require.NoError(t, os.WriteFile(file, []byte("a: 1\nincludeFiles: [declared.yaml]\n"), 0600))
+	require.NoError(t, cnf.TryReload())
+	assert.Equal(t, 3, cnf.Int("c"))
+
+	require.NoError(t, os.WriteFile(file, []byte("a: 1\n"), 0600))
+	require.NoError(t, os.WriteFile(include, []byte("b: 4\n"), 0600))
+	require.NoError(t, cnf.TryReload())
+	assert.Equal(t, map[string]any{"a": 1, "b": 4}, cnf.AllSettings(), "the declared include files should be reset")
+}
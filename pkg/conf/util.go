@@ -89,12 +89,13 @@ func GetIP(useIPv6 bool) string {
 	panic("Unable to determine local IP address (non loopback).")
 }
 
-// TryLoadEnvFromFile try load env from files
+// TryLoadEnvFromFile try load env from files in the scan dir. The files are ".env", ".env.local", and if mod is set,
+// ".env.{mod}" and ".env.{mod}.local", the latter file overrides the former.
 func TryLoadEnvFromFile(scan, mod string) {
 	files := make([]string, len(defaultEnvFiles))
 	copy(files, defaultEnvFiles)
 	if mod != "" {
-		files = append(files, fmt.Sprintf(".env.%s", mod))
+		files = append(files, fmt.Sprintf(".env.%s", mod), fmt.Sprintf(".env.%s.local", mod))
 	}
 	for _, name := range files {
 		fp := filepath.Join(scan, name)
@@ -0,0 +1,142 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-viper/mapstructure/v2"
+)
+
+const defaultTagName = "default"
+
+var (
+	validateOnce sync.Once
+	validate     *validator.Validate
+)
+
+func getValidator() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+		// use the configuration key as the field name in errors.
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			switch name {
+			case "-":
+				return ""
+			case "":
+				return field.Name
+			}
+			return name
+		})
+	})
+	return validate
+}
+
+// RegisterValidation adds a validation with the tag to the validator used by Validate.
+func RegisterValidation(tag string, fn validator.Func) error {
+	return getValidator().RegisterValidation(tag, fn)
+}
+
+// unmarshalStrict applies the `default` tags, decodes the configuration and reports the keys not matching any field,
+// then validates the result by the `validate` tags.
+func (c *Configuration) unmarshalStrict(dst any) error {
+	if err := ApplyDefaults(dst); err != nil {
+		return err
+	}
+	md := &mapstructure.Metadata{}
+	dc := decoderConfig(dst)
+	dc.Metadata = md
+	decoder, err := mapstructure.NewDecoder(dc)
+	if err != nil {
+		return err
+	}
+	if err = decoder.Decode(c.Parser().ToStringMap()); err != nil {
+		return fmt.Errorf("conf: unmarshal %s: %w", c.pathOrRoot(), err)
+	}
+	if len(md.Unused) > 0 {
+		sort.Strings(md.Unused)
+		keys := make([]string, len(md.Unused))
+		for i, k := range md.Unused {
+			keys[i] = joinPath(c.prefix, k)
+		}
+		return fmt.Errorf("conf: unknown keys: %s", strings.Join(keys, ", "))
+	}
+	return c.Validate(dst)
+}
+
+func (c *Configuration) pathOrRoot() string {
+	if c.prefix == "" {
+		return "<root>"
+	}
+	return c.prefix
+}
+
+// Validate validates the struct by the `validate` tags, see github.com/go-playground/validator. The errors report the
+// full configuration path of the fields, such as "web.server.addr".
+func (c *Configuration) Validate(v any) error {
+	err := getValidator().Struct(v)
+	var ves validator.ValidationErrors
+	if !errors.As(err, &ves) {
+		return err
+	}
+	errs := make([]error, len(ves))
+	for i, fe := range ves {
+		// the namespace starts with the struct name.
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		msg := fe.Tag()
+		if fe.Param() != "" {
+			msg += "=" + fe.Param()
+		}
+		errs[i] = fmt.Errorf("conf: %s: failed on the '%s' validation, got %v", joinPath(c.prefix, field), msg, fe.Value())
+	}
+	return errors.Join(errs...)
+}
+
+// ApplyDefaults sets the zero fields of the struct pointer by the `default` tags, the tag value is decoded
+// as a configuration value, so that "1m" is for time.Duration and "a,b" is for []string. The nested structs
+// and the non-nil struct pointers are applied recursively.
+func ApplyDefaults(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("conf: apply defaults: %T is not a non-nil pointer", v)
+	}
+	return applyDefaults(rv.Elem())
+}
+
+func applyDefaults(rv reflect.Value) error {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf, fv := rt.Field(i), rv.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if def, ok := sf.Tag.Lookup(defaultTagName); ok && fv.IsZero() {
+			decoder, err := mapstructure.NewDecoder(decoderConfig(fv.Addr().Interface()))
+			if err != nil {
+				return err
+			}
+			if err = decoder.Decode(def); err != nil {
+				return fmt.Errorf("conf: default value of field %s: %w", sf.Name, err)
+			}
+			continue
+		}
+		if err := applyDefaults(fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package conf
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type strictDB struct {
+	DSN          string        `json:"dsn" validate:"required"`
+	MaxOpenConns int           `json:"maxOpenConns" validate:"min=1" default:"10"`
+	MaxIdleTime  time.Duration `json:"maxIdleTime" default:"1m"`
+}
+
+type strictConfig struct {
+	Name     string         `json:"name" default:"app"`
+	Tags     []string       `json:"tags" default:"a,b"`
+	Size     ByteSize       `json:"size" default:"1KB"`
+	Location *time.Location `json:"location"`
+	Endpoint *url.URL       `json:"endpoint"`
+	DB       strictDB       `json:"db"`
+}
+
+func TestConfiguration_UnmarshalStrict(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cnf := NewFromBytes([]byte(`
+location: Asia/Shanghai
+endpoint: https://woocoo.com/api
+db:
+  dsn: root@localhost
+`), WithStrict())
+		var v strictConfig
+		require.NoError(t, cnf.Unmarshal(&v))
+		assert.Equal(t, "app", v.Name)
+		assert.Equal(t, []string{"a", "b"}, v.Tags)
+		assert.Equal(t, KB, v.Size)
+		assert.Equal(t, "Asia/Shanghai", v.Location.String())
+		assert.Equal(t, "woocoo.com", v.Endpoint.Host)
+		assert.Equal(t, 10, v.DB.MaxOpenConns)
+		assert.Equal(t, time.Minute, v.DB.MaxIdleTime)
+	})
+	t.Run("override defaults", func(t *testing.T) {
+		cnf := NewFromBytes([]byte(`
+name: test
+size: 10MB
+db:
+  dsn: root@localhost
+  maxOpenConns: 20
+`), WithStrict())
+		v := strictConfig{}
+		require.NoError(t, cnf.Unmarshal(&v))
+		assert.Equal(t, "test", v.Name)
+		assert.Equal(t, 10*MB, v.Size)
+		assert.Equal(t, 20, v.DB.MaxOpenConns)
+	})
+	t.Run("unknown keys", func(t *testing.T) {
+		cnf := NewFromBytes([]byte(`
+db:
+  db:
+    dsn: root@localhost
+    maxOpenConn: 20
+`), WithStrict())
+		var v strictConfig
+		err := cnf.Sub("db").Unmarshal(&v)
+		assert.EqualError(t, err, "conf: unknown keys: db.db.maxOpenConn")
+	})
+	t.Run("validate", func(t *testing.T) {
+		cnf := NewFromBytes([]byte(`
+app:
+  db:
+    maxOpenConns: -1
+`), WithStrict())
+		var v strictConfig
+		err := cnf.Sub("app").Unmarshal(&v)
+		assert.ErrorContains(t, err, "conf: app.db.dsn: failed on the 'required' validation")
+		assert.ErrorContains(t, err, "conf: app.db.maxOpenConns: failed on the 'min=1' validation, got -1")
+	})
+	t.Run("decode error", func(t *testing.T) {
+		cnf := NewFromBytes([]byte(`
+app:
+  location: Unknown/Zone
+`), WithStrict())
+		var v strictConfig
+		assert.ErrorContains(t, cnf.Sub("app").Unmarshal(&v), "conf: unmarshal app:")
+	})
+	t.Run("not strict", func(t *testing.T) {
+		cnf := NewFromBytes([]byte(`
+size: 2MB
+db:
+  maxOpenConn: 20
+`))
+		var v strictConfig
+		require.NoError(t, cnf.Unmarshal(&v))
+		assert.Equal(t, 2*MB, v.Size)
+		assert.Equal(t, "", v.Name)
+		assert.False(t, cnf.IsStrict())
+	})
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ByteSize
+		wantErr bool
+	}{
+		{in: "100", want: 100},
+		{in: "512KB", want: 512 * KB},
+		{in: "1.5 mb", want: ByteSize(1.5 * float64(MB))},
+		{in: "1GiB", want: GB},
+		{in: "2t", want: 2 * TB},
+		{in: "MB", wantErr: true},
+		{in: "10XB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseByteSize(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	assert.Equal(t, "10MB", (10 * MB).String())
+	assert.Equal(t, "1025B", ByteSize(1025).String())
+}
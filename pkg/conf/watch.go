@@ -0,0 +1,265 @@
+package conf
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	defaultWatchDebounce = 100 * time.Millisecond
+	// watchPollInterval is the interval of checking files if the file system notification is unavailable.
+	watchPollInterval = time.Second
+)
+
+// fileWatcher watches the local file, the include files and the sources of a root Configuration, and reloads
+// the configuration after any of them changed.
+//
+// The directories of the files are watched instead of the files, so that the atomic replacement such as editors
+// saving or Kubernetes ConfigMap updating the symlink can be detected.
+type fileWatcher struct {
+	root     *Configuration
+	debounce time.Duration
+	watcher  *fsnotify.Watcher
+
+	mu sync.Mutex
+	// files maps the watched file to its resolved real path and file info.
+	files map[string]fileState
+	dirs  map[string]struct{}
+	timer *time.Timer
+	// cancel stops watching the sources of the current configuration.
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+type fileState struct {
+	real    string
+	size    int64
+	modTime time.Time
+}
+
+func statFile(name string) fileState {
+	st := fileState{}
+	st.real, _ = filepath.EvalSymlinks(name)
+	if fi, err := os.Stat(name); err == nil {
+		st.size, st.modTime = fi.Size(), fi.ModTime()
+	}
+	return st
+}
+
+func newFileWatcher(root *Configuration) *fileWatcher {
+	fw := &fileWatcher{
+		root:     root,
+		debounce: root.opts.watchDebounce,
+		files:    make(map[string]fileState),
+		dirs:     make(map[string]struct{}),
+		done:     make(chan struct{}),
+	}
+	if fw.debounce <= 0 {
+		fw.debounce = defaultWatchDebounce
+	}
+	return fw
+}
+
+// start begins watching, it falls back to polling if the file system notification is unavailable.
+func (fw *fileWatcher) start() {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("conf: file system notification is unavailable, fallback to polling: %v", err)
+	} else {
+		fw.watcher = w
+	}
+	fw.syncFiles()
+	fw.syncSources()
+	if fw.watcher != nil {
+		go fw.watch()
+	} else {
+		go fw.poll()
+	}
+}
+
+// syncFiles refreshes the watched files by the current configuration, the include files may change after reloading.
+func (fw *fileWatcher) syncFiles() {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	fw.root.subs.filesMu.RLock()
+	opts := fw.root.opts
+	names := make([]string, 0, len(opts.includeFiles)+1)
+	if opts.localPath != "" {
+		names = append(names, opts.localPath)
+	}
+	names = append(names, opts.includeFiles...)
+	fw.root.subs.filesMu.RUnlock()
+	// the profile file is watched even if it does not exist, so that creating it takes effect.
+	if pf := fw.root.profilePath(); pf != "" {
+		names = append(names, pf)
+	}
+	files := make(map[string]fileState, len(names))
+	for _, name := range names {
+		name = filepath.Clean(name)
+		files[name] = statFile(name)
+		dir := filepath.Dir(name)
+		if _, ok := fw.dirs[dir]; ok || fw.watcher == nil {
+			continue
+		}
+		if err := fw.watcher.Add(dir); err != nil {
+			log.Printf("conf: watch directory %s error: %v", dir, err)
+			continue
+		}
+		fw.dirs[dir] = struct{}{}
+	}
+	fw.files = files
+}
+
+// syncSources restarts watching the sources, the sources are recreated after reloading.
+func (fw *fileWatcher) syncSources() {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.cancel != nil {
+		fw.cancel()
+	}
+	var ctx context.Context
+	ctx, fw.cancel = context.WithCancel(context.Background())
+	fw.root.subs.filesMu.RLock()
+	sources := fw.root.sources
+	fw.root.subs.filesMu.RUnlock()
+	for _, src := range sources {
+		go func(src *loadedSource) {
+			if err := src.Watch(ctx, fw.schedule); err != nil {
+				log.Printf("conf: watch source %q error: %v", src.Scheme, err)
+			}
+		}(src)
+	}
+}
+
+func (fw *fileWatcher) watch() {
+	for {
+		select {
+		case <-fw.done:
+			return
+		case event, ok := <-fw.watcher.Events:
+			if !ok {
+				return
+			}
+			if fw.isChanged(event.Name) {
+				fw.schedule()
+			}
+		case err, ok := <-fw.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("conf: watch files error: %v", err)
+		}
+	}
+}
+
+func (fw *fileWatcher) poll() {
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-fw.done:
+			return
+		case <-ticker.C:
+			if fw.isChanged("") {
+				fw.schedule()
+			}
+		}
+	}
+}
+
+// isChanged reports whether the watched files changed. name is the file of the event, the event of a watched
+// file is always a change, other events in the watched directories are checked by the real path and the file info
+// of the watched files, such as the symlink swapping of Kubernetes ConfigMap.
+func (fw *fileWatcher) isChanged(name string) bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	name = filepath.Clean(name)
+	res := false
+	for file, st := range fw.files {
+		cur := statFile(file)
+		if file == name || cur != st {
+			fw.files[file] = cur
+			res = true
+		}
+	}
+	return res
+}
+
+// schedule delays the reloading until no change happens in the debounce duration.
+func (fw *fileWatcher) schedule() {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.timer != nil {
+		fw.timer.Stop()
+	}
+	fw.timer = time.AfterFunc(fw.debounce, fw.reload)
+}
+
+func (fw *fileWatcher) reload() {
+	select {
+	case <-fw.done:
+		return
+	default:
+	}
+	if err := fw.root.TryReload(); err != nil {
+		log.Printf("conf: reload configuration error, keep the previous configuration: %v", err)
+		return
+	}
+	fw.syncFiles()
+	fw.syncSources()
+}
+
+func (fw *fileWatcher) stop() {
+	fw.once.Do(func() {
+		close(fw.done)
+		fw.mu.Lock()
+		if fw.timer != nil {
+			fw.timer.Stop()
+		}
+		if fw.cancel != nil {
+			fw.cancel()
+		}
+		fw.mu.Unlock()
+		if fw.watcher != nil {
+			fw.watcher.Close()
+		}
+	})
+}
+
+// Watch watches the local file, the include files and the sources, and calls cb with the new sub Configuration of
+// path after the subtree of path changed. The path is relative to the current Configuration, empty path means the
+// current node. sub is nil if the path is removed.
+//
+// The changes are debounced, see WithWatchDebounce. If the changed files are invalid, the error is logged
+// and the previous configuration is kept. Watching starts at the first call and lasts until StopWatch is called.
+func (c *Configuration) Watch(path string, cb func(sub *Configuration)) {
+	c.Subscribe(path, func(_, new *Configuration) {
+		cb(new)
+	})
+	root := c.Root()
+	root.subs.mu.Lock()
+	defer root.subs.mu.Unlock()
+	if root.watcher != nil {
+		return
+	}
+	root.watcher = newFileWatcher(root)
+	root.watcher.start()
+}
+
+// StopWatch stops watching files started by Watch, the subscriptions are kept.
+func (c *Configuration) StopWatch() {
+	root := c.Root()
+	root.subs.mu.Lock()
+	defer root.subs.mu.Unlock()
+	if root.watcher != nil {
+		root.watcher.stop()
+		root.watcher = nil
+	}
+}
@@ -0,0 +1,112 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfiguration_Watch(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	include := filepath.Join(dir, "include.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+includeFiles:
+  - include.yaml
+web:
+  addr: :8080
+cache:
+  ttl: 1m
+`), 0600))
+	require.NoError(t, os.WriteFile(include, []byte(`
+cache:
+  size: 100
+`), 0600))
+	cnf := New(WithBaseDir(dir), WithLocalPath(file), WithWatchDebounce(time.Millisecond*50)).Load()
+	webCh, cacheCh := make(chan *Configuration, 10), make(chan *Configuration, 10)
+	cnf.Watch("web", func(sub *Configuration) {
+		webCh <- sub
+	})
+	cnf.Watch("cache", func(sub *Configuration) {
+		cacheCh <- sub
+	})
+	defer cnf.StopWatch()
+
+	wait := func(ch chan *Configuration) *Configuration {
+		select {
+		case sub := <-ch:
+			return sub
+		case <-time.After(time.Second * 3):
+			require.Fail(t, "watch callback is not called")
+		}
+		return nil
+	}
+	t.Run("local file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(`
+includeFiles:
+  - include.yaml
+web:
+  addr: :9090
+cache:
+  ttl: 1m
+`), 0600))
+		sub := wait(webCh)
+		assert.Equal(t, ":9090", sub.String("addr"))
+		assert.Equal(t, ":9090", cnf.String("web.addr"))
+		assert.Len(t, cacheCh, 0)
+	})
+	t.Run("include file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(include, []byte(`
+cache:
+  size: 200
+`), 0600))
+		sub := wait(cacheCh)
+		assert.Equal(t, 200, sub.Int("size"))
+		assert.Len(t, webCh, 0)
+	})
+	t.Run("atomic replace", func(t *testing.T) {
+		tmp := filepath.Join(dir, "include.yaml.tmp")
+		require.NoError(t, os.WriteFile(tmp, []byte(`
+cache:
+  size: 300
+`), 0600))
+		require.NoError(t, os.Rename(tmp, include))
+		sub := wait(cacheCh)
+		assert.Equal(t, 300, sub.Int("size"))
+	})
+	t.Run("parse error", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte("web: [addr"), 0600))
+		time.Sleep(time.Millisecond * 300)
+		assert.Len(t, webCh, 0)
+		assert.Equal(t, ":9090", cnf.String("web.addr"))
+		assert.Equal(t, 300, cnf.Int("cache.size"))
+	})
+	t.Run("stop", func(t *testing.T) {
+		cnf.StopWatch()
+		require.NoError(t, os.WriteFile(file, []byte("web:\n  addr: :7070\n"), 0600))
+		time.Sleep(time.Millisecond * 300)
+		assert.Len(t, webCh, 0)
+		assert.Equal(t, ":9090", cnf.String("web.addr"))
+	})
+}
+
+func TestFileWatcher_poll(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("web:\n  addr: :8080\n"), 0600))
+	cnf := New(WithBaseDir(dir), WithLocalPath(file)).Load()
+	fw := newFileWatcher(cnf)
+	fw.syncFiles()
+	assert.False(t, fw.isChanged(""))
+	require.NoError(t, os.WriteFile(file, []byte("web:\n  addr: :9090\n"), 0600))
+	require.NoError(t, os.Chtimes(file, time.Now(), time.Now().Add(time.Second)))
+	assert.True(t, fw.isChanged(""))
+	assert.False(t, fw.isChanged(""))
+	fw.reload()
+	assert.Equal(t, ":9090", cnf.String("web.addr"))
+	fw.stop()
+}
@@ -42,6 +42,8 @@ type (
 		Authorization *Authorization `yaml:"authorization,omitempty" json:"authorization,omitempty"`
 		// The OAuth2 client credentials used to fetch a token for the targets.
 		OAuth2 *OAuth2Config `yaml:"oauth2,omitempty" json:"oauth2,omitempty"`
+		// LogLevel propagates the elevated log level of the request context to the hosts, see PropagateLogLevel.
+		LogLevel *LogLevelPropagation `yaml:"logLevel,omitempty" json:"logLevel,omitempty"`
 
 		base http.RoundTripper
 	}
@@ -57,6 +59,15 @@ type (
 		HeaderPrefix string `yaml:"headerPrefix,omitempty" json:"headerPrefix,omitempty"`
 	}
 
+	// LogLevelPropagation is the settings to propagate the elevated log level, the level is only propagated to
+	// the Hosts.
+	LogLevelPropagation struct {
+		// Header is the header to pass the signed token, default is log.DefaultLevelHeader.
+		Header string `yaml:"header,omitempty" json:"header,omitempty"`
+		// Hosts are the hostnames or host:port of the downstream services.
+		Hosts []string `yaml:"hosts" json:"hosts"`
+	}
+
 	// BasicAuth contains basic HTTP authentication credentials.
 	BasicAuth struct {
 		Username string `yaml:"username" json:"username"`
@@ -86,6 +97,9 @@ func NewClientConfig(cnf *conf.Configuration, opts ...Option) (cfg *ClientConfig
 	if cfg.BasicAuth != nil {
 		cfg.base = chain(cfg.base, BaseAuth(cfg.BasicAuth.Username, cfg.BasicAuth.Password))
 	}
+	if cfg.LogLevel != nil && len(cfg.LogLevel.Hosts) > 0 {
+		cfg.base = chain(cfg.base, PropagateLogLevel(cfg.LogLevel.Header, cfg.LogLevel.Hosts))
+	}
 	if cfg.OAuth2 != nil && cfg.OAuth2.StoreKey != "" {
 		storage, err := newCacheTokenStorage(cfg)
 		if err != nil {
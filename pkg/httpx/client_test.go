@@ -21,7 +21,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/pkg/log"
 	"github.com/tsingsun/woocoo/test/testdata"
+	"go.uber.org/zap/zapcore"
 )
 
 const (
@@ -498,6 +500,52 @@ func TestNewClient_Option(t *testing.T) {
 	}
 }
 
+func TestPropagateLogLevel(t *testing.T) {
+	ts, err := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.Header.Get("X-Level"))
+	}, false)
+	require.NoError(t, err)
+	defer ts.Close()
+	tsURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	newClient := func(hosts ...string) *http.Client {
+		cfg, err := NewClientConfig(conf.NewFromStringMap(map[string]any{
+			"logLevel": map[string]any{"header": "X-Level", "hosts": hosts},
+		}))
+		require.NoError(t, err)
+		client, err := cfg.Client(context.Background(), nil)
+		require.NoError(t, err)
+		return client
+	}
+	elevated := log.WithLevelOverride(context.Background(), log.LevelOverride{Level: zapcore.DebugLevel, Token: "t1"})
+	tests := []struct {
+		name   string
+		client *http.Client
+		ctx    context.Context
+		want   string
+	}{
+		{name: "none", client: newClient(tsURL.Host), ctx: context.Background()},
+		{name: "token", client: newClient(tsURL.Host), ctx: elevated, want: "t1"},
+		{name: "hostname", client: newClient("other", strings.ToUpper(tsURL.Hostname())), ctx: elevated, want: "t1"},
+		{name: "claim", client: newClient(tsURL.Host), ctx: log.WithLevelOverride(context.Background(), log.LevelOverride{Level: zapcore.DebugLevel})},
+		{name: "other host", client: newClient("other:80"), ctx: elevated},
+		{name: "no hosts", client: newClient(), ctx: elevated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := tt.client
+			req, err := http.NewRequestWithContext(tt.ctx, http.MethodGet, ts.URL, nil)
+			require.NoError(t, err)
+			res, err := client.Do(req)
+			require.NoError(t, err)
+			bd, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(bd))
+			assert.Empty(t, req.Header.Get("X-Level"), "should not modify the request of caller")
+		})
+	}
+}
+
 func TestOAuth2(t *testing.T) {
 	tokencount := 0
 	const wantAuth = "/auth?client_id=client_id&response_type=code&scope=scope1+scope2&state=state"
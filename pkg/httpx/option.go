@@ -1,8 +1,11 @@
 package httpx
 
 import (
+	"github.com/tsingsun/woocoo/pkg/log"
 	"golang.org/x/oauth2"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
 type Option func(c *ClientConfig)
@@ -69,3 +72,35 @@ func BaseAuth(username, password string) Middleware {
 		})
 	}
 }
+
+// PropagateLogLevel is a middleware that passes the elevated log level of the request context to the downstream
+// service by the header, default is log.DefaultLevelHeader. The level elevated by the claim is not propagated.
+//
+// The signed token is only sent to the hosts, a host is the hostname or the host:port of the request URL,
+// so that it is not leaked to the third-party services.
+func PropagateLogLevel(header string, hosts []string) Middleware {
+	if header == "" {
+		header = log.DefaultLevelHeader
+	}
+	allowed := make(map[string]struct{}, len(hosts))
+	for _, host := range hosts {
+		allowed[strings.ToLower(host)] = struct{}{}
+	}
+	isAllowed := func(u *url.URL) bool {
+		if _, ok := allowed[strings.ToLower(u.Host)]; ok {
+			return true
+		}
+		_, ok := allowed[strings.ToLower(u.Hostname())]
+		return ok
+	}
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return internalRoundTripper(func(req *http.Request) (*http.Response, error) {
+			if o, ok := log.LevelOverrideFromContext(req.Context()); ok && o.Token != "" && req.Header.Get(header) == "" &&
+				isAllowed(req.URL) {
+				req = req.Clone(req.Context())
+				req.Header.Set(header, o.Token)
+			}
+			return rt.RoundTrip(req)
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// asyncSchema is the sink schema of the outputs of async cores, the host is the id of the pending sink.
+	asyncSchema = "woocoo-async"
+
+	// AsyncPolicyBlock blocks the writing until the buffer has room.
+	AsyncPolicyBlock = "block"
+	// AsyncPolicyDropOldest drops the oldest entry in the buffer to make room.
+	AsyncPolicyDropOldest = "dropOldest"
+
+	defaultAsyncBufferSize    = 1024
+	defaultAsyncFlushInterval = time.Second
+)
+
+var (
+	asyncSinkID    atomic.Uint64
+	asyncPendingMu sync.Mutex
+	asyncPending   = map[string]asyncSinkSpec{}
+	// asyncOpened are the writers opened by zap for the async sinks, they are taken by the Config building them.
+	asyncOpened    = map[string]*AsyncWriter{}
+	asyncWritersMu sync.Mutex
+	asyncWriters   []*AsyncWriter
+)
+
+func init() {
+	if err := zap.RegisterSink(asyncSchema, newAsyncSink); err != nil {
+		panic(err)
+	}
+}
+
+// AsyncConfig is the settings of the asynchronous writing of a core, the entries are written to a bounded buffer and
+// flushed to the outputs in batches by a background goroutine. Configuration example:
+//
+//	cores:
+//	  - level: info
+//	    outputPaths: [logs/app.log]
+//	    async:
+//	      bufferSize: 4096
+//	      flushInterval: 1s
+//	      policy: dropOldest
+type AsyncConfig struct {
+	// BufferSize is the max number of entries in the buffer, default is 1024. A batch is flushed when the buffer
+	// is half full.
+	BufferSize int `json:"bufferSize" yaml:"bufferSize" validate:"min=0"`
+	// FlushInterval is the max interval between two flushes, default is 1s.
+	FlushInterval time.Duration `json:"flushInterval" yaml:"flushInterval" validate:"min=0"`
+	// Policy is the behavior when the buffer is full: "block" (default) waits for the flushing,
+	// "dropOldest" drops the oldest entry and counts it, see AsyncWriter.Dropped.
+	Policy string `json:"policy" yaml:"policy" validate:"omitempty,oneof=block dropOldest"`
+}
+
+type asyncSinkSpec struct {
+	path string
+	cfg  AsyncConfig
+}
+
+// AsyncWriter is a zapcore.WriteSyncer which writes to a bounded ring buffer and flushes to the underlying
+// WriteSyncer in batches. Sync flushes the buffer and syncs the underlying WriteSyncer, log.Sync calls it through
+// the cores.
+type AsyncWriter struct {
+	ws       zapcore.WriteSyncer
+	interval time.Duration
+	dropOld  bool
+
+	mu       sync.Mutex
+	notFull  *sync.Cond
+	ring     [][]byte
+	head     int
+	size     int
+	closed   bool
+	dropped  atomic.Uint64
+	flushMu  sync.Mutex
+	batch    bytes.Buffer
+	wakeCh   chan struct{}
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	spareBuf [][]byte
+}
+
+// NewAsyncWriter creates an AsyncWriter over ws and starts its flushing goroutine.
+func NewAsyncWriter(ws zapcore.WriteSyncer, cfg AsyncConfig) *AsyncWriter {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultAsyncBufferSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultAsyncFlushInterval
+	}
+	w := &AsyncWriter{
+		ws:       ws,
+		interval: cfg.FlushInterval,
+		dropOld:  cfg.Policy == AsyncPolicyDropOldest,
+		ring:     make([][]byte, cfg.BufferSize),
+		wakeCh:   make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+	asyncWritersMu.Lock()
+	asyncWriters = append(asyncWriters, w)
+	asyncWritersMu.Unlock()
+	go w.run()
+	return w
+}
+
+// Write copies p into the buffer, it writes to the underlying WriteSyncer directly after closed.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	for !w.closed && w.size == len(w.ring) {
+		if w.dropOld {
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+			w.size--
+			w.dropped.Add(1)
+			break
+		}
+		w.wake()
+		w.notFull.Wait()
+	}
+	if w.closed {
+		w.mu.Unlock()
+		w.flushMu.Lock()
+		defer w.flushMu.Unlock()
+		return w.ws.Write(p)
+	}
+	w.ring[(w.head+w.size)%len(w.ring)] = append([]byte(nil), p...)
+	w.size++
+	if w.size >= len(w.ring)/2 {
+		w.wake()
+	}
+	w.mu.Unlock()
+	return len(p), nil
+}
+
+// wake notifies the flushing goroutine without blocking.
+func (w *AsyncWriter) wake() {
+	select {
+	case w.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.doneCh)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+		case <-w.wakeCh:
+		}
+		_ = w.flush()
+	}
+}
+
+// flush writes the buffered entries to the underlying WriteSyncer in one batch.
+func (w *AsyncWriter) flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	w.mu.Lock()
+	if w.size == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	entries := w.spareBuf[:0]
+	for i := 0; i < w.size; i++ {
+		idx := (w.head + i) % len(w.ring)
+		entries = append(entries, w.ring[idx])
+		w.ring[idx] = nil
+	}
+	w.head, w.size = 0, 0
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	w.batch.Reset()
+	for _, e := range entries {
+		w.batch.Write(e)
+	}
+	clear(entries)
+	w.spareBuf = entries
+	_, err := w.ws.Write(w.batch.Bytes())
+	return err
+}
+
+// Sync flushes the buffered entries and syncs the underlying WriteSyncer.
+func (w *AsyncWriter) Sync() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.ws.Sync()
+}
+
+// Dropped returns the number of the entries dropped by the "dropOldest" policy.
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Close stops the flushing goroutine and flushes the buffered entries, the later writing goes to the underlying
+// WriteSyncer directly.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+	close(w.stopCh)
+	<-w.doneCh
+	asyncWritersMu.Lock()
+	asyncWriters = slices.DeleteFunc(asyncWriters, func(aw *AsyncWriter) bool { return aw == w })
+	asyncWritersMu.Unlock()
+	return w.Sync()
+}
+
+// AsyncDropped returns the total number of the entries dropped by all open AsyncWriters.
+func AsyncDropped() uint64 {
+	asyncWritersMu.Lock()
+	defer asyncWritersMu.Unlock()
+	var n uint64
+	for _, w := range asyncWriters {
+		n += w.Dropped()
+	}
+	return n
+}
+
+// asyncOutputPath registers the output path as a pending async sink and returns the path of the async sink.
+func asyncOutputPath(path string, cfg AsyncConfig) string {
+	id := strconv.FormatUint(asyncSinkID.Add(1), 10)
+	asyncPendingMu.Lock()
+	asyncPending[id] = asyncSinkSpec{path: path, cfg: cfg}
+	asyncPendingMu.Unlock()
+	return asyncSchema + "://" + id
+}
+
+// takeAsyncWriter returns the writer opened for the async sink path and forgets it, it returns nil if the sink is not
+// opened.
+func takeAsyncWriter(path string) *AsyncWriter {
+	id := strings.TrimPrefix(path, asyncSchema+"://")
+	asyncPendingMu.Lock()
+	defer asyncPendingMu.Unlock()
+	w := asyncOpened[id]
+	delete(asyncOpened, id)
+	delete(asyncPending, id)
+	return w
+}
+
+// closeAsyncWriters closes the writers and joins the errors.
+func closeAsyncWriters(ws []*AsyncWriter) error {
+	var err error
+	for _, w := range ws {
+		err = errors.Join(err, w.Close())
+	}
+	return err
+}
+
+// asyncSink wraps the underlying sink to close both of them.
+type asyncSink struct {
+	*AsyncWriter
+	closeOutput func()
+}
+
+func (s *asyncSink) Close() error {
+	err := s.AsyncWriter.Close()
+	s.closeOutput()
+	return err
+}
+
+func newAsyncSink(u *url.URL) (zap.Sink, error) {
+	asyncPendingMu.Lock()
+	spec, ok := asyncPending[u.Host]
+	delete(asyncPending, u.Host)
+	asyncPendingMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("async sink %q not found", u.String())
+	}
+	ws, closeOutput, err := zap.Open(spec.path)
+	if err != nil {
+		return nil, err
+	}
+	sink := &asyncSink{AsyncWriter: NewAsyncWriter(ws, spec.cfg), closeOutput: closeOutput}
+	asyncPendingMu.Lock()
+	asyncOpened[u.Host] = sink.AsyncWriter
+	asyncPendingMu.Unlock()
+	return sink, nil
+}
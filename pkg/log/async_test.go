@@ -0,0 +1,233 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/conf"
+)
+
+// gateSyncer records the writes, the writing waits until the gate is opened.
+type gateSyncer struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+	gate   chan struct{}
+}
+
+func (g *gateSyncer) Write(p []byte) (int, error) {
+	if g.gate != nil {
+		<-g.gate
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writes++
+	return g.buf.Write(p)
+}
+
+func (g *gateSyncer) Sync() error { return nil }
+
+func (g *gateSyncer) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	t.Run("batch", func(t *testing.T) {
+		ws := &gateSyncer{}
+		w := NewAsyncWriter(ws, AsyncConfig{BufferSize: 10, FlushInterval: time.Hour})
+		defer w.Close()
+		for _, s := range []string{"a\n", "b\n", "c\n"} {
+			_, err := w.Write([]byte(s))
+			require.NoError(t, err)
+		}
+		assert.Empty(t, ws.String(), "should be buffered")
+		require.NoError(t, w.Sync())
+		assert.Equal(t, "a\nb\nc\n", ws.String())
+		assert.Equal(t, 1, ws.writes, "should be written in one batch")
+	})
+	t.Run("interval", func(t *testing.T) {
+		ws := &gateSyncer{}
+		w := NewAsyncWriter(ws, AsyncConfig{BufferSize: 10, FlushInterval: 10 * time.Millisecond})
+		defer w.Close()
+		_, err := w.Write([]byte("a\n"))
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			return ws.String() == "a\n"
+		}, time.Second, 5*time.Millisecond)
+	})
+	t.Run("half full", func(t *testing.T) {
+		ws := &gateSyncer{}
+		w := NewAsyncWriter(ws, AsyncConfig{BufferSize: 4, FlushInterval: time.Hour})
+		defer w.Close()
+		for _, s := range []string{"a\n", "b\n"} {
+			_, err := w.Write([]byte(s))
+			require.NoError(t, err)
+		}
+		assert.Eventually(t, func() bool {
+			return ws.String() == "a\nb\n"
+		}, time.Second, 5*time.Millisecond)
+	})
+	t.Run("dropOldest", func(t *testing.T) {
+		ws := &gateSyncer{}
+		w := NewAsyncWriter(ws, AsyncConfig{BufferSize: 2, FlushInterval: time.Hour, Policy: AsyncPolicyDropOldest})
+		// blocks the flushing goroutine by the flushing lock.
+		w.flushMu.Lock()
+		for _, s := range []string{"a\n", "b\n", "c\n", "d\n"} {
+			_, err := w.Write([]byte(s))
+			require.NoError(t, err)
+		}
+		assert.EqualValues(t, 2, w.Dropped())
+		assert.EqualValues(t, 2, AsyncDropped())
+		w.flushMu.Unlock()
+		require.NoError(t, w.Sync())
+		assert.Equal(t, "c\nd\n", ws.String())
+		require.NoError(t, w.Close())
+		assert.Zero(t, AsyncDropped(), "closed writer should be removed")
+	})
+	t.Run("block", func(t *testing.T) {
+		ws := &gateSyncer{gate: make(chan struct{})}
+		w := NewAsyncWriter(ws, AsyncConfig{BufferSize: 2, FlushInterval: time.Hour})
+		_, err := w.Write([]byte("a\n"))
+		require.NoError(t, err)
+		// the flushing goroutine takes "a" and waits the gate.
+		assert.Eventually(t, func() bool {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			return w.size == 0
+		}, time.Second, 5*time.Millisecond)
+		for _, s := range []string{"b\n", "c\n"} {
+			_, err := w.Write([]byte(s))
+			require.NoError(t, err)
+		}
+		written := make(chan struct{})
+		go func() {
+			_, _ = w.Write([]byte("d\n"))
+			close(written)
+		}()
+		select {
+		case <-written:
+			t.Fatal("should block when the buffer is full")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(ws.gate)
+		<-written
+		require.NoError(t, w.Close())
+		assert.Equal(t, "a\nb\nc\nd\n", ws.String())
+		assert.Zero(t, w.Dropped())
+	})
+	t.Run("closed", func(t *testing.T) {
+		ws := &gateSyncer{}
+		w := NewAsyncWriter(ws, AsyncConfig{FlushInterval: time.Hour})
+		_, err := w.Write([]byte("a\n"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		assert.Equal(t, "a\n", ws.String(), "should flush when closing")
+		_, err = w.Write([]byte("b\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "a\nb\n", ws.String(), "should write directly after closed")
+		assert.NoError(t, w.Close())
+	})
+}
+
+func TestConfig_Async(t *testing.T) {
+	t.Run("core", func(t *testing.T) {
+		dir := t.TempDir()
+		asyncFile, syncFile := filepath.Join(dir, "async.log"), filepath.Join(dir, "sync.log")
+		cfg := conf.NewFromStringMap(map[string]any{
+			"disableSampling": true,
+			"cores": []any{
+				map[string]any{"level": "info", "outputPaths": []any{asyncFile},
+					"async": map[string]any{"bufferSize": 100, "flushInterval": "1h"}},
+				map[string]any{"level": "info", "outputPaths": []any{syncFile}},
+			},
+		}, conf.WithStrict())
+		config, err := NewConfig(cfg)
+		require.NoError(t, err)
+		require.Len(t, config.asyncs, 2)
+		assert.Equal(t, AsyncConfig{BufferSize: 100, FlushInterval: time.Hour}, *config.asyncs[0])
+		assert.Nil(t, config.asyncs[1])
+
+		zl, err := config.BuildZap()
+		require.NoError(t, err)
+		logger := New(zl)
+		logger.Info("async-message")
+		bs, err := os.ReadFile(asyncFile)
+		require.NoError(t, err)
+		assert.Empty(t, bs)
+		bs, err = os.ReadFile(syncFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(bs), "async-message")
+
+		require.NoError(t, logger.Sync())
+		bs, err = os.ReadFile(asyncFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(bs), "async-message")
+	})
+	t.Run("close", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "async.log")
+		cfg := conf.NewFromStringMap(map[string]any{
+			"disableSampling": true,
+			"cores": []any{
+				map[string]any{"level": "info", "outputPaths": []any{file},
+					"async": map[string]any{"flushInterval": "1h"}},
+			},
+		})
+		opened := func(w *AsyncWriter) bool {
+			asyncWritersMu.Lock()
+			defer asyncWritersMu.Unlock()
+			return slices.Contains(asyncWriters, w)
+		}
+		logger := NewFromConf(cfg)
+		require.Len(t, logger.asyncWriters, 1)
+		first := logger.asyncWriters[0]
+		assert.True(t, opened(first))
+
+		logger.Info("before-rebuild")
+		logger.Apply(cfg)
+		require.Len(t, logger.asyncWriters, 1)
+		assert.False(t, opened(first), "the writer of the rebuilt logger should be closed")
+		assert.True(t, opened(logger.asyncWriters[0]))
+		bs, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Contains(t, string(bs), "before-rebuild")
+
+		second := logger.asyncWriters[0]
+		logger.Info("before-close")
+		require.NoError(t, logger.Close())
+		assert.False(t, opened(second))
+		assert.Empty(t, logger.asyncWriters)
+		bs, err = os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Contains(t, string(bs), "before-close")
+		require.NoError(t, logger.Close())
+	})
+	t.Run("invalid policy", func(t *testing.T) {
+		cfg := conf.NewFromStringMap(map[string]any{
+			"cores": []any{
+				map[string]any{"outputPaths": []any{"stdout"}, "async": map[string]any{"policy": "drop"}},
+			},
+		})
+		_, err := NewConfig(cfg)
+		assert.ErrorContains(t, err, "unknown policy")
+	})
+}
+
+func BenchmarkAsyncWriter(b *testing.B) {
+	w := NewAsyncWriter(&gateSyncer{}, AsyncConfig{Policy: AsyncPolicyDropOldest})
+	defer w.Close()
+	line := []byte(strings.Repeat("x", 128) + "\n")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = w.Write(line)
+	}
+}
@@ -42,6 +42,8 @@ type (
 		l             *Logger
 		cl            *Logger
 		builtInFields []zap.Field
+		// level is the level of the component, nil for the global component.
+		level *componentLevel
 		// useGlobal is true if the component is using the global logger.
 		useGlobal bool
 	}
@@ -73,6 +75,7 @@ func Component(name string, fields ...zap.Field) ComponentLogger {
 	c := &component{
 		name:          name,
 		builtInFields: append(fields, zap.String(ComponentKey, name)),
+		level:         getComponentLevel(name),
 		useGlobal:     true,
 	}
 	c.Init()
@@ -107,7 +110,13 @@ func (c *component) SetLogger(logger *Logger) {
 	c.useGlobal = logger == global
 	if c.logger != logger {
 		c.logger = logger
-		c.l = logger.With(c.builtInFields...)
+		c.l = logger
+		if c.level != nil {
+			c.l = c.l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+				return wrapComponentCore(core, c.level)
+			}))
+		}
+		c.l = c.l.With(c.builtInFields...)
 		c.cl = c.l.WithOptions(zap.AddCallerSkip(CallerSkip + 1))
 	}
 }
@@ -212,10 +221,17 @@ func (c *LoggerWithCtx) Log(lvl zapcore.Level, msg string, fields []zap.Field) {
 
 func (c *LoggerWithCtx) logFields(ctx context.Context, lvl zapcore.Level, msg string, fields []zap.Field) {
 	defer PutLoggerWithCtx(c)
+	if cf := ContextFields(ctx); len(cf) > 0 {
+		fields = append(fields[:len(fields):len(fields)], cf...)
+	}
 	c.l.contextLogger.LogFields(c.l, ctx, lvl, msg, fields)
 }
 
-// NewLoggerWithCtx get a logger with context from pool
+// NewLoggerWithCtx get a logger with context from pool, the LevelOverride in the context lowers the level of
+// the logger for the request.
 func NewLoggerWithCtx(ctx context.Context, l *Logger) *LoggerWithCtx {
+	if o, ok := LevelOverrideFromContext(ctx); ok {
+		l = l.withLevelOverride(o.Level)
+	}
 	return GetLoggerWithCtx(ctx, l)
 }
@@ -8,8 +8,11 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
 	"github.com/tsingsun/woocoo/pkg/conf"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -18,6 +21,10 @@ import (
 
 const (
 	zapConfigPath = "cores"
+	// asyncConfigKey is the key of AsyncConfig in a core.
+	asyncConfigKey = "async"
+	// followComponentsKey is the key of a core to follow the levels of components lower than its own level.
+	followComponentsKey = "followComponents"
 	// rotate:[//[userinfo@]host][/]path[?query][#fragment]
 	rotateSchema = "Rotate"
 
@@ -31,14 +38,21 @@ const (
 	GrpcComponentName = "grpc"
 )
 
-var once sync.Once
+var (
+	once       sync.Once
+	rotateOnce sync.Once
+	// rotateConfig is the rotate settings of the Config building the loggers, the Rotate sink uses it.
+	rotateConfig atomic.Pointer[rotate]
+)
 
 // Config is logger schema
 // ZapConfigs use as zap advance,such as zapcore.NewTee()
 // Sole use as one zap logger core
 type Config struct {
-	// ZapConfigs is for initial zap multi core
-	ZapConfigs []zap.Config `json:"cores" yaml:"cores"`
+	// ZapConfigs is for initial zap multi core, a core writes asynchronously by the "async" key, see AsyncConfig.
+	// A core with "followComponents: true" logs the entries of a component at its level even if lower than the level
+	// of the core, see Components.
+	ZapConfigs []zap.Config `json:"cores" yaml:"cores" validate:"min=1"`
 	// Rotate is for log rotate
 	Rotate *rotate `json:"rotate" yaml:"rotate"`
 	// DisableSampling disables sampling for all the loggers
@@ -51,15 +65,53 @@ type Config struct {
 	// WithTraceID configures the logger to add `trace_id` field to structured log messages.
 	WithTraceID bool `json:"withTraceID" yaml:"withTraceID"`
 	// TraceIDKey is the key used to store the trace ID. defaults to "trace_id".
-	TraceIDKey string `json:"traceIDKey" yaml:"traceIDKey"`
-	callerSkip int
-	useRotate  bool
-	basedir    string
+	TraceIDKey string `json:"traceIDKey" yaml:"traceIDKey" validate:"required" default:"trace_id"`
+	// CallerSkip is the number of stack frames to skip when logging caller, defaults to 1.
+	CallerSkip int `json:"callerSkip" yaml:"callerSkip" validate:"min=0"`
+	// Components are the settings of the component loggers by the component name, such as:
+	//
+	//	components:
+	//	  grpc:
+	//	    level: debug
+	//
+	// The level of a component is a bound on top of the level of each core, so the component can only raise the
+	// levels of the cores, except the cores with "followComponents: true".
+	Components map[string]ComponentConfig `json:"components" yaml:"components"`
+	// Redact enables masking the sensitive values of fields in all cores, see RedactConfig.
+	Redact *RedactConfig `json:"redact" yaml:"redact"`
+	// ContextFields adds the fields such as the user id and the request id from the context, see ContextFieldsConfig.
+	ContextFields *ContextFieldsConfig `json:"contextFields" yaml:"contextFields"`
+	useRotate     bool
+	basedir       string
+	redactor      *Redactor
+	// asyncs are the async settings by the index of cores, nil means writing synchronously.
+	asyncs []*AsyncConfig
+	// follows are the "followComponents" settings by the index of cores.
+	follows []bool
+	// asyncWriters are the writers of the async cores built by BuildZap.
+	asyncWriters []*AsyncWriter
+}
+
+// ComponentConfig is the settings of a component logger.
+type ComponentConfig struct {
+	// Level is the level of the component on top of the levels of the cores, empty means following the cores.
+	Level string `json:"level" yaml:"level"`
 }
 
 type rotate struct {
 	// mapstructor use ",squash" tag for embedded struct, but conf.decoderConfig use `squash=true` so need not set
 	lumberjack.Logger `json:",inline" yaml:",inline"`
+	// Interval enables the time rotation by "hourly" or "daily", empty means the size rotation by MaxSize.
+	// The output path can be a file name pattern with the time verbs %Y %m %d %H, such as "logs/app-%Y%m%d.log",
+	// the path without time verbs such as "logs/app.log" uses the pattern "logs/app-%Y%m%d.log" or
+	// "logs/app-%Y%m%d%H.log" by the interval. MaxSize is ignored in the time rotation.
+	Interval string `json:"interval" yaml:"interval"`
+	// MaxTotalSize is the maximum total size in megabytes of the log files of an output in the time rotation,
+	// the oldest rotated files are removed when exceeded. Default is not to remove by size.
+	MaxTotalSize int `json:"maxTotalSize" yaml:"maxTotalSize"`
+	// Symlink creates the symlink to the current file in the time rotation, the symlink is the output path without
+	// time verbs, such as "logs/app.log".
+	Symlink bool `json:"symlink" yaml:"symlink"`
 }
 
 // Sync implement zap.Sink interface
@@ -71,33 +123,108 @@ func (r *rotate) Sync() error {
 
 // NewConfig return a Config instance
 func NewConfig(cnf *conf.Configuration) (*Config, error) {
-	coresl := len(cnf.ParserOperator().Slices(zapConfigPath))
+	cores := cnf.ParserOperator().Slices(zapConfigPath)
+	coresl := len(cores)
 	if coresl == 0 {
 		return nil, fmt.Errorf("none logger config,plz set up section: cores")
 	}
 	v := &Config{
 		ZapConfigs: make([]zap.Config, coresl),
 		basedir:    cnf.Root().GetBaseDir(),
-		callerSkip: CallerSkip,
+		CallerSkip: CallerSkip,
 		TraceIDKey: TraceIDKey,
 	}
-	if cs := "callerSkip"; cnf.IsSet(cs) {
-		v.callerSkip = cnf.Int(cs)
-	}
 	for i := 0; i < len(v.ZapConfigs); i++ {
 		v.ZapConfigs[i] = defaultZapConfig(cnf)
 	}
+	var err error
+	if v.asyncs, v.follows, cnf, err = cutCoreConfigs(cnf, cores); err != nil {
+		return nil, err
+	}
 
-	if err := cnf.Unmarshal(&v); err != nil {
+	if err := cnf.Unmarshal(v); err != nil {
 		return nil, err
 	}
 
+	for name, cc := range v.Components {
+		if cc.Level == "" {
+			continue
+		}
+		if _, err := zapcore.ParseLevel(cc.Level); err != nil {
+			return nil, fmt.Errorf("level of component %s: %w", name, err)
+		}
+	}
 	if v.Rotate != nil || cnf.IsSet("rotate") {
 		v.useRotate = true
 	}
+	if v.Rotate != nil {
+		switch v.Rotate.Interval {
+		case "", RotateHourly, RotateDaily:
+		default:
+			return nil, fmt.Errorf("unknown rotate interval %q", v.Rotate.Interval)
+		}
+	}
+	if v.Redact != nil {
+		if v.redactor, err = NewRedactor(*v.Redact); err != nil {
+			return nil, err
+		}
+	}
 	return v, nil
 }
 
+// cutCoreConfigs decodes the async and the followComponents settings of the cores, and returns the configuration
+// without them because they are not the fields of zap.Config.
+func cutCoreConfigs(cnf *conf.Configuration, cores []*koanf.Koanf) ([]*AsyncConfig, []bool, *conf.Configuration, error) {
+	var (
+		asyncs  []*AsyncConfig
+		follows []bool
+		cut     bool
+	)
+	for i, core := range cores {
+		if core.Exists(followComponentsKey) {
+			if follows == nil {
+				follows = make([]bool, len(cores))
+			}
+			follows[i] = core.Bool(followComponentsKey)
+			cut = true
+		}
+		if !core.Exists(asyncConfigKey) {
+			continue
+		}
+		ac, err := conf.Bind[AsyncConfig](conf.NewFromStringMap(core.Raw()), asyncConfigKey)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("async of core %d: %w", i, err)
+		}
+		switch ac.Policy {
+		case "", AsyncPolicyBlock, AsyncPolicyDropOldest:
+		default:
+			return nil, nil, nil, fmt.Errorf("async of core %d: unknown policy %q", i, ac.Policy)
+		}
+		if asyncs == nil {
+			asyncs = make([]*AsyncConfig, len(cores))
+		}
+		asyncs[i] = &ac
+		cut = true
+	}
+	if !cut {
+		return nil, nil, cnf, nil
+	}
+	raw := cnf.ParserOperator().Raw()
+	if rcs, ok := raw[zapConfigPath].([]any); ok {
+		for _, rc := range rcs {
+			if m, ok := rc.(map[string]any); ok {
+				delete(m, asyncConfigKey)
+				delete(m, followComponentsKey)
+			}
+		}
+	}
+	k := koanf.New(conf.KeyDelimiter)
+	if err := k.Load(confmap.Provider(raw, ""), nil); err != nil {
+		return nil, nil, nil, err
+	}
+	return asyncs, follows, cnf.CutFromOperator(k), nil
+}
+
 // DefaultTimeEncoder serializes time.Time to a human-readable formatted string
 func DefaultTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 	if e, ok := enc.(*TextEncoder); ok {
@@ -148,9 +275,20 @@ func (c *Config) BuildZap(opts ...zap.Option) (zl *zap.Logger, err error) {
 		if err != nil {
 			panic(err)
 		}
-
-		// RegisterSink
-		if c.useRotate {
+	})
+	encoderConfig.Store(&encoderOptions{
+		disableErrorVerbose: c.DisableErrorVerbose,
+		disableTimestamp:    c.DisableTimestamp,
+		traceIDKey:          c.TraceIDKey,
+	})
+	// RegisterSink
+	if c.useRotate {
+		r := c.Rotate
+		if r == nil {
+			r = &rotate{}
+		}
+		rotateConfig.Store(r)
+		rotateOnce.Do(func() {
 			err := zap.RegisterSink(rotateSchema, func(u *url.URL) (zap.Sink, error) {
 				if u.User != nil {
 					return nil, fmt.Errorf("user and password not allowed with file URLs: got %v", u)
@@ -168,22 +306,29 @@ func (c *Config) BuildZap(opts ...zap.Option) (zl *zap.Logger, err error) {
 				if hn := u.Hostname(); hn != "" && hn != "localhost" {
 					return nil, fmt.Errorf("file URLs must leave host empty or use localhost: got %v", u)
 				}
-				l := c.newRotateWriter()
+				filename := u.Path
 				if runtime.GOOS == "windows" {
-					l.Filename = strings.TrimPrefix(u.Path, "/")
-				} else {
-					l.Filename = u.Path
+					filename = strings.TrimPrefix(u.Path, "/")
+				}
+				r := rotateConfig.Load()
+				if r.Interval != "" {
+					return openTimeRotateWriter(filename, r)
 				}
+				if hasTimeVerb(filename) {
+					return nil, fmt.Errorf("time verbs in file name need rotate interval: got %v", filename)
+				}
+				l := newRotateWriter(r)
+				l.Filename = filename
 				return l, nil
 			})
 			if err != nil {
 				panic(err)
 			}
-		}
-	})
+		})
+	}
 
 	var (
-		cores []zapcore.Core
+		cores levelTee
 		copts []zap.Option
 	)
 	for i := range c.ZapConfigs {
@@ -192,20 +337,57 @@ func (c *Config) BuildZap(opts ...zap.Option) (zl *zap.Logger, err error) {
 		if err != nil {
 			return nil, err
 		}
+		var asyncPaths []string
+		if i < len(c.asyncs) && c.asyncs[i] != nil {
+			for j, path := range zc.OutputPaths {
+				zc.OutputPaths[j] = asyncOutputPath(path, *c.asyncs[i])
+			}
+			asyncPaths = zc.OutputPaths
+		}
+		// the core accepts all levels, levelCore filters the entries by the configured level and the component level.
+		level := zc.Level
+		zc.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+		// the sampler is applied on top of the wrapping cores instead of by zap, so they can check by Enabled.
+		// slogCallerCore is built in so that the records of SlogHandler are sampled as well.
+		sampling := zc.Sampling
+		zc.Sampling = nil
 		tmpzl, err := zc.Build()
+		for _, path := range asyncPaths {
+			if w := takeAsyncWriter(path); w != nil && err == nil {
+				c.asyncWriters = append(c.asyncWriters, w)
+			}
+		}
 		if err != nil {
+			_ = closeAsyncWriters(c.asyncWriters)
+			c.asyncWriters = nil
 			return nil, err
 		}
-		cores = append(cores, tmpzl.Core())
+		cores = append(cores, &levelCore{
+			Core:   newSamplerCore(&slogCallerCore{Core: newRedactCore(tmpzl.Core(), c.redactor)}, sampling),
+			level:  level,
+			follow: i < len(c.follows) && c.follows[i],
+		})
 		if i == 0 {
 			copts = c.buildZapOptions(&zc)
 		}
 	}
 	opts = append(opts, copts...)
-	zl = zap.New(zapcore.NewTee(cores...), opts...)
+	zl = zap.New(cores, opts...)
 	return
 }
 
+// newSamplerCore samples the entries of the core like zap.Config.Build, the core is returned if sampling is nil.
+func newSamplerCore(core zapcore.Core, sampling *zap.SamplingConfig) zapcore.Core {
+	if sampling == nil {
+		return core
+	}
+	var opts []zapcore.SamplerOption
+	if sampling.Hook != nil {
+		opts = append(opts, zapcore.SamplerHook(sampling.Hook))
+	}
+	return zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter, opts...)
+}
+
 func (c *Config) buildZapOptions(cfg *zap.Config) (opts []zap.Option) {
 	if !cfg.DisableCaller {
 		opts = append(opts, zap.AddCaller())
@@ -222,14 +404,14 @@ func (c *Config) buildZapOptions(cfg *zap.Config) (opts []zap.Option) {
 	return opts
 }
 
-func (c *Config) newRotateWriter() *rotate {
+func newRotateWriter(r *rotate) *rotate {
 	return &rotate{
 		Logger: lumberjack.Logger{
-			MaxSize:    c.Rotate.MaxSize,
-			MaxAge:     c.Rotate.MaxAge,
-			MaxBackups: c.Rotate.MaxBackups,
-			LocalTime:  c.Rotate.LocalTime,
-			Compress:   c.Rotate.Compress,
+			MaxSize:    r.MaxSize,
+			MaxAge:     r.MaxAge,
+			MaxBackups: r.MaxBackups,
+			LocalTime:  r.LocalTime,
+			Compress:   r.Compress,
 		},
 	}
 }
@@ -240,6 +422,8 @@ func convertPath(path string, base string, useRotate bool) (cp string, err error
 		if isFile {
 			err = os.MkdirAll(filepath.Dir(cp), 0755)
 			if useRotate {
+				// escape the time verbs of the time rotation in URL.
+				cp = strings.ReplaceAll(cp, "%", "%25")
 				if runtime.GOOS == "windows" {
 					cp = rotateSchema + ":///" + cp
 				} else {
@@ -255,6 +439,15 @@ func convertPath(path string, base string, useRotate bool) (cp string, err error
 		isFile = true
 		return path, nil
 	}
+	if isNetPath(path) {
+		return resolveNetPath(path, base)
+	}
+	if hasTimeVerb(path) {
+		// the file name pattern of the time rotation is not a valid URL.
+		cp = filepath.Join(base, path)
+		isFile = true
+		return
+	}
 	uri, err := url.Parse(path)
 	if err != nil {
 		return "", fmt.Errorf("can't parse %q as a URL: %v", path, err)
@@ -96,6 +96,41 @@ cores:
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "strict",
+			args: args{
+				cfg: conf.NewFromBytes([]byte(`
+callerSkip: 2
+cores:
+  - level: debug
+    outputPaths:
+      - stdout
+rotate:
+  maxSize: 10
+`), conf.WithStrict()),
+			},
+			check: func(cfg *Config) {
+				assert.Equal(t, 2, cfg.CallerSkip)
+				assert.Equal(t, TraceIDKey, cfg.TraceIDKey)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "strict unknown key",
+			args: args{
+				cfg: conf.NewFromBytes([]byte(`
+cores:
+  - level: debug
+disableSample: true
+`), conf.WithStrict()),
+			},
+			check: func(cfg *Config) {
+				assert.Nil(t, cfg)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...any) bool {
+				return assert.EqualError(t, err, "conf: unknown keys: disableSample")
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -141,7 +176,7 @@ log:
 		useRotate:  true,
 		ZapConfigs: make([]zap.Config, 1),
 		Rotate: &rotate{
-			lumberjack.Logger{
+			Logger: lumberjack.Logger{
 				MaxSize:    1,
 				MaxAge:     1,
 				MaxBackups: 1,
@@ -250,7 +285,7 @@ func TestConfig_BuildZap(t *testing.T) {
 					zap.NewDevelopmentConfig(),
 				},
 				Rotate: &rotate{
-					lumberjack.Logger{
+					Logger: lumberjack.Logger{
 						MaxSize:    1,
 						MaxAge:     1,
 						MaxBackups: 1,
@@ -0,0 +1,143 @@
+package log
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tsingsun/woocoo/pkg/security"
+	"go.uber.org/zap"
+)
+
+const (
+	// RequestIDContextKey is the key of context which stores the request id, the access logs set it from the
+	// "X-Request-Id" header of web requests or the "x-request-id" metadata of gRPC calls.
+	// It is a string key so that it can be set by gin.Context.Set too.
+	RequestIDContextKey = "woocoo_request_id"
+	// DefaultTenantClaim is the default claim name of the tenant id.
+	DefaultTenantClaim = "tenant_id"
+)
+
+// contextFields is the ContextFieldsConfig in use, nil means no context fields.
+var contextFields atomic.Pointer[contextFieldsConfig]
+
+// ContextFieldsConfig is the settings of the fields that LoggerWithCtx adds from the context automatically,
+// the access logs of web, gRPC and GraphQL streams add them too. The field is not logged if its key is empty or
+// the value is not in the context. Configuration example:
+//
+//	contextFields:
+//	  userID: user_id
+//	  tenantID: tenant_id
+//	  tenantClaim: tid
+//	  requestID: request_id
+//	  claims:
+//	    email: user_email
+type ContextFieldsConfig struct {
+	// UserID is the field key of the user id which is the Identity().Name() of the security.Principal in the context.
+	UserID string `json:"userID" yaml:"userID"`
+	// Claims maps the claim names of the principal to the field keys.
+	Claims map[string]string `json:"claims" yaml:"claims"`
+	// TenantID is the field key of the tenant id which is from the claim of TenantClaim.
+	TenantID string `json:"tenantID" yaml:"tenantID"`
+	// TenantClaim is the claim name of the tenant id, default is DefaultTenantClaim.
+	TenantClaim string `json:"tenantClaim" yaml:"tenantClaim"`
+	// RequestID is the field key of the request id which is from the context value of RequestIDContextKey.
+	RequestID string `json:"requestID" yaml:"requestID"`
+}
+
+type claimField struct {
+	claim string
+	key   string
+}
+
+// contextFieldsConfig is the ContextFieldsConfig with the claims sorted for the stable output.
+type contextFieldsConfig struct {
+	ContextFieldsConfig
+	claims []claimField
+}
+
+// SetContextFields sets the context fields of all the loggers, nil disables it. Logger.Apply sets it by the
+// configuration "contextFields".
+func SetContextFields(cfg *ContextFieldsConfig) {
+	if cfg == nil {
+		contextFields.Store(nil)
+		return
+	}
+	c := &contextFieldsConfig{ContextFieldsConfig: *cfg}
+	if c.TenantClaim == "" {
+		c.TenantClaim = DefaultTenantClaim
+	}
+	for claim, key := range cfg.Claims {
+		if key != "" {
+			c.claims = append(c.claims, claimField{claim: claim, key: key})
+		}
+	}
+	sort.Slice(c.claims, func(i, j int) bool {
+		return c.claims[i].key < c.claims[j].key
+	})
+	contextFields.Store(c)
+}
+
+// WithRequestID returns a context with the request id which is logged as the RequestID field of ContextFieldsConfig.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, RequestIDContextKey, id) // nolint: staticcheck
+}
+
+// ContextFields returns the fields of the request id and the principal in the context by ContextFieldsConfig.
+func ContextFields(ctx context.Context) []zap.Field {
+	c := contextFields.Load()
+	if c == nil || ctx == nil {
+		return nil
+	}
+	var fields []zap.Field
+	if c.RequestID != "" {
+		if id, ok := ctx.Value(RequestIDContextKey).(string); ok && id != "" {
+			fields = append(fields, zap.String(c.RequestID, id))
+		}
+	}
+	if p, ok := security.FromContext(ctx); ok {
+		fields = c.appendPrincipal(fields, p)
+	}
+	return fields
+}
+
+// PrincipalFields returns the fields of the principal by ContextFieldsConfig. It is used to carry the principal
+// fields to the access log which can't see the principal in the context, such as the gRPC JWT interceptor.
+func PrincipalFields(p security.Principal) []zap.Field {
+	c := contextFields.Load()
+	if c == nil {
+		return nil
+	}
+	return c.appendPrincipal(nil, p)
+}
+
+func (c *contextFieldsConfig) appendPrincipal(fields []zap.Field, p security.Principal) []zap.Field {
+	if p == nil || p.Identity() == nil {
+		return fields
+	}
+	identity := p.Identity()
+	if c.UserID != "" {
+		if name := identity.Name(); name != "" {
+			fields = append(fields, zap.String(c.UserID, name))
+		}
+	}
+	if c.TenantID == "" && len(c.claims) == 0 {
+		return fields
+	}
+	claims, ok := identity.Claims().(jwt.MapClaims)
+	if !ok {
+		return fields
+	}
+	if c.TenantID != "" {
+		if v, ok := claims[c.TenantClaim]; ok {
+			fields = append(fields, zap.Any(c.TenantID, v))
+		}
+	}
+	for _, cf := range c.claims {
+		if v, ok := claims[cf.claim]; ok {
+			fields = append(fields, zap.Any(cf.key, v))
+		}
+	}
+	return fields
+}
@@ -0,0 +1,107 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/pkg/security"
+	"github.com/tsingsun/woocoo/test/logtest"
+	"go.uber.org/zap"
+)
+
+func TestContextFields(t *testing.T) {
+	t.Cleanup(func() {
+		SetContextFields(nil)
+	})
+	principal := security.NewGenericPrincipalByClaims(jwt.MapClaims{
+		"sub":       "1",
+		"tenant_id": "t1",
+		"tid":       "t2",
+		"email":     "a@b.c",
+	})
+	ctx := WithRequestID(security.WithContext(context.Background(), principal), "r1")
+	tests := []struct {
+		name string
+		cfg  *ContextFieldsConfig
+		ctx  context.Context
+		want []zap.Field
+	}{
+		{
+			name: "disabled",
+			ctx:  ctx,
+		},
+		{
+			name: "all",
+			cfg: &ContextFieldsConfig{
+				UserID: "user_id", TenantID: "tenant", RequestID: "request_id",
+				Claims: map[string]string{"email": "user_email", "miss": "miss", "sub": ""},
+			},
+			ctx: ctx,
+			want: []zap.Field{
+				zap.String("request_id", "r1"), zap.String("user_id", "1"),
+				zap.Any("tenant", "t1"), zap.Any("user_email", "a@b.c"),
+			},
+		},
+		{
+			name: "tenant claim",
+			cfg:  &ContextFieldsConfig{TenantID: "tenant", TenantClaim: "tid"},
+			ctx:  ctx,
+			want: []zap.Field{zap.Any("tenant", "t2")},
+		},
+		{
+			name: "empty context",
+			cfg:  &ContextFieldsConfig{UserID: "user_id", RequestID: "request_id"},
+			ctx:  context.Background(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetContextFields(tt.cfg)
+			assert.Equal(t, tt.want, ContextFields(tt.ctx))
+		})
+	}
+	t.Run("principal", func(t *testing.T) {
+		SetContextFields(&ContextFieldsConfig{UserID: "user_id", RequestID: "request_id"})
+		assert.Equal(t, []zap.Field{zap.String("user_id", "1")}, PrincipalFields(principal))
+		SetContextFields(nil)
+		assert.Nil(t, PrincipalFields(principal))
+	})
+}
+
+func TestLoggerWithCtx_ContextFields(t *testing.T) {
+	cfg := conf.NewFromStringMap(map[string]any{
+		"cores": []any{map[string]any{"level": "debug"}},
+		"contextFields": map[string]any{
+			"userID":    "user_id",
+			"requestID": "request_id",
+		},
+	})
+	config, err := NewConfig(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, config.ContextFields)
+	assert.Equal(t, "user_id", config.ContextFields.UserID)
+
+	SetContextFields(config.ContextFields)
+	t.Cleanup(func() {
+		SetContextFields(nil)
+	})
+	logdata := &logtest.Buffer{}
+	logger := New(logtest.NewBuffLogger(logdata))
+	ctx := security.WithContext(WithRequestID(context.Background(), "r1"),
+		security.NewGenericPrincipalByClaims(jwt.MapClaims{"sub": "1"}))
+	fields := make([]zap.Field, 1, 2)
+	fields[0] = zap.String("k", "v")
+	logger.Ctx(ctx).Info("hello", fields...)
+	logger.Ctx(context.Background()).Info("anonymous")
+
+	lines := logdata.Lines()
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"user_id":"1"`)
+	assert.Contains(t, lines[0], `"request_id":"r1"`)
+	assert.NotContains(t, lines[1], "user_id")
+	assert.Equal(t, zap.Field{}, fields[:2][1], "should not write to the fields of caller")
+}
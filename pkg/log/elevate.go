@@ -0,0 +1,208 @@
+package log
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tsingsun/woocoo/pkg/security"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// DefaultLevelHeader is the default header to elevate the log level of a request.
+	DefaultLevelHeader = "X-Debug-Log"
+	// LevelOverrideContextKey is the key of context which stores the LevelOverride.
+	// It is a string key so that it can be set by gin.Context.Set too.
+	LevelOverrideContextKey = "woocoo_log_level"
+)
+
+// LevelOverride is the log level of a request which overrides the levels of the cores and the components,
+// the LoggerWithCtx of the context logs the entries at or above the level.
+type LevelOverride struct {
+	Level zapcore.Level
+	// Token is the header value elevating the level, it is propagated to the outbound calls.
+	// It is empty if the level is elevated by the claim.
+	Token string
+}
+
+// WithLevelOverride returns a context with the LevelOverride.
+func WithLevelOverride(ctx context.Context, o LevelOverride) context.Context {
+	return context.WithValue(ctx, LevelOverrideContextKey, o) // nolint: staticcheck
+}
+
+// LevelOverrideFromContext returns the LevelOverride in the context, if any.
+func LevelOverrideFromContext(ctx context.Context) (LevelOverride, bool) {
+	if ctx == nil {
+		return LevelOverride{}, false
+	}
+	o, ok := ctx.Value(LevelOverrideContextKey).(LevelOverride)
+	return o, ok
+}
+
+// withLevelOverride returns a logger enabling the level for the cores built by Config, other loggers are returned
+// as it is because their levels can't be lowered.
+func (l *Logger) withLevelOverride(lvl zapcore.Level) *Logger {
+	if l == nil || l.Logger == nil {
+		return l
+	}
+	if _, ok := l.Logger.Core().(levelTee); !ok {
+		return l
+	}
+	return l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return core.(levelTee).withOverride(lvl)
+	}))
+}
+
+// LevelElevationConfig is the settings to elevate the log level of a request, the web middleware and the gRPC
+// interceptor named "logLevel" use it. Configuration example:
+//
+//	logLevel:
+//	  header: X-Debug-Log
+//	  level: debug
+//	  secret: your-secret
+//	  claim: debug_log
+//
+// The level is elevated if any of the ways passes:
+//   - TrustHeader: the header value is "1", "true" or a level name, only for the services behind a trusted gateway.
+//   - Secret: the header value is a token signed by SignLevelToken with the secret.
+//   - Claim: the claim of the security.Principal in the context is true, "1", "true" or a level name.
+type LevelElevationConfig struct {
+	// Header is the header carrying the level, default is DefaultLevelHeader. The gRPC interceptor uses the
+	// lower case of it as the metadata key.
+	Header string `json:"header" yaml:"header"`
+	// Level is the elevated level when the value is not a level name, default is debug.
+	Level string `json:"level" yaml:"level"`
+	// TrustHeader accepts the plain header value.
+	TrustHeader bool `json:"trustHeader" yaml:"trustHeader"`
+	// Secret is the HMAC secret to verify the signed token in the header.
+	Secret string `json:"secret" yaml:"secret"`
+	// Claim is the claim name of the principal to elevate the level.
+	Claim string `json:"claim" yaml:"claim"`
+}
+
+// LevelElevator checks whether a request elevates the log level by LevelElevationConfig.
+type LevelElevator struct {
+	header      string
+	level       zapcore.Level
+	trustHeader bool
+	secret      []byte
+	claim       string
+}
+
+// NewLevelElevator creates a LevelElevator, it returns error if the level is invalid.
+func NewLevelElevator(cfg LevelElevationConfig) (*LevelElevator, error) {
+	e := &LevelElevator{
+		header:      cfg.Header,
+		level:       zapcore.DebugLevel,
+		trustHeader: cfg.TrustHeader,
+		secret:      []byte(cfg.Secret),
+		claim:       cfg.Claim,
+	}
+	if e.header == "" {
+		e.header = DefaultLevelHeader
+	}
+	if cfg.Level != "" {
+		var err error
+		if e.level, err = zapcore.ParseLevel(cfg.Level); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Header returns the header carrying the level.
+func (e *LevelElevator) Header() string {
+	return e.header
+}
+
+// Check returns the LevelOverride by the header value or the principal in the context.
+func (e *LevelElevator) Check(ctx context.Context, value string) (LevelOverride, bool) {
+	if value != "" {
+		if len(e.secret) > 0 {
+			if lvl, err := ParseLevelToken(e.secret, value); err == nil {
+				return LevelOverride{Level: lvl, Token: value}, true
+			}
+		}
+		if e.trustHeader {
+			if lvl, ok := e.parseValue(value); ok {
+				return LevelOverride{Level: lvl, Token: value}, true
+			}
+		}
+	}
+	if e.claim != "" {
+		if p, ok := security.FromContext(ctx); ok && p.Identity() != nil {
+			if claims, ok := p.Identity().Claims().(jwt.MapClaims); ok {
+				if lvl, ok := e.parseClaim(claims[e.claim]); ok {
+					return LevelOverride{Level: lvl}, true
+				}
+			}
+		}
+	}
+	return LevelOverride{}, false
+}
+
+func (e *LevelElevator) parseValue(value string) (zapcore.Level, bool) {
+	switch strings.ToLower(value) {
+	case "1", "true":
+		return e.level, true
+	}
+	lvl, err := zapcore.ParseLevel(value)
+	return lvl, err == nil
+}
+
+func (e *LevelElevator) parseClaim(v any) (zapcore.Level, bool) {
+	switch cv := v.(type) {
+	case bool:
+		return e.level, cv
+	case float64:
+		return e.level, cv == 1
+	case string:
+		return e.parseValue(cv)
+	}
+	return e.level, false
+}
+
+// SignLevelToken returns a token of the level valid until the expiry, which elevates the log level by the header
+// if the LevelElevationConfig.Secret is set. The format is "<level>.<expiry unix seconds>.<signature>".
+func SignLevelToken(secret []byte, level zapcore.Level, expires time.Time) string {
+	payload := level.String() + "." + strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + levelTokenSignature(secret, payload)
+}
+
+// ParseLevelToken verifies the token signed by SignLevelToken and returns the level.
+func ParseLevelToken(secret []byte, token string) (zapcore.Level, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return zapcore.InvalidLevel, errors.New("invalid level token")
+	}
+	payload, sig := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(levelTokenSignature(secret, payload))) {
+		return zapcore.InvalidLevel, errors.New("invalid level token signature")
+	}
+	lvlText, expText, ok := strings.Cut(payload, ".")
+	if !ok {
+		return zapcore.InvalidLevel, errors.New("invalid level token")
+	}
+	exp, err := strconv.ParseInt(expText, 10, 64)
+	if err != nil {
+		return zapcore.InvalidLevel, err
+	}
+	if time.Now().Unix() > exp {
+		return zapcore.InvalidLevel, errors.New("level token expired")
+	}
+	return zapcore.ParseLevel(lvlText)
+}
+
+func levelTokenSignature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
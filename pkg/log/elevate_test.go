@@ -0,0 +1,120 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/security"
+	"github.com/tsingsun/woocoo/test/logtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelToken(t *testing.T) {
+	secret := []byte("secret")
+	token := SignLevelToken(secret, zapcore.DebugLevel, time.Now().Add(time.Minute))
+	lvl, err := ParseLevelToken(secret, token)
+	require.NoError(t, err)
+	assert.Equal(t, zapcore.DebugLevel, lvl)
+
+	_, err = ParseLevelToken([]byte("other"), token)
+	assert.ErrorContains(t, err, "signature")
+	_, err = ParseLevelToken(secret, SignLevelToken(secret, zapcore.DebugLevel, time.Now().Add(-time.Minute)))
+	assert.ErrorContains(t, err, "expired")
+	_, err = ParseLevelToken(secret, "debug")
+	assert.Error(t, err)
+}
+
+func TestLevelElevator(t *testing.T) {
+	secret := "secret"
+	token := SignLevelToken([]byte(secret), zapcore.InfoLevel, time.Now().Add(time.Minute))
+	claimCtx := func(v any) context.Context {
+		return security.WithContext(context.Background(), security.NewGenericPrincipalByClaims(jwt.MapClaims{"debug_log": v}))
+	}
+	tests := []struct {
+		name  string
+		cfg   LevelElevationConfig
+		ctx   context.Context
+		value string
+		want  LevelOverride
+		ok    bool
+	}{
+		{name: "untrusted header", ctx: context.Background(), value: "1"},
+		{name: "trusted header", cfg: LevelElevationConfig{TrustHeader: true}, ctx: context.Background(), value: "1",
+			want: LevelOverride{Level: zapcore.DebugLevel, Token: "1"}, ok: true},
+		{name: "trusted level name", cfg: LevelElevationConfig{TrustHeader: true}, ctx: context.Background(), value: "warn",
+			want: LevelOverride{Level: zapcore.WarnLevel, Token: "warn"}, ok: true},
+		{name: "trusted invalid", cfg: LevelElevationConfig{TrustHeader: true}, ctx: context.Background(), value: "x"},
+		{name: "signed", cfg: LevelElevationConfig{Secret: secret}, ctx: context.Background(), value: token,
+			want: LevelOverride{Level: zapcore.InfoLevel, Token: token}, ok: true},
+		{name: "signed invalid", cfg: LevelElevationConfig{Secret: secret}, ctx: context.Background(), value: "1"},
+		{name: "claim bool", cfg: LevelElevationConfig{Claim: "debug_log"}, ctx: claimCtx(true),
+			want: LevelOverride{Level: zapcore.DebugLevel}, ok: true},
+		{name: "claim level", cfg: LevelElevationConfig{Claim: "debug_log", Level: "info"}, ctx: claimCtx("1"),
+			want: LevelOverride{Level: zapcore.InfoLevel}, ok: true},
+		{name: "claim false", cfg: LevelElevationConfig{Claim: "debug_log"}, ctx: claimCtx(false)},
+		{name: "claim without principal", cfg: LevelElevationConfig{Claim: "debug_log"}, ctx: context.Background()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewLevelElevator(tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, DefaultLevelHeader, e.Header())
+			got, ok := e.Check(tt.ctx, tt.value)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	_, err := NewLevelElevator(LevelElevationConfig{Level: "x"})
+	assert.Error(t, err)
+}
+
+func TestLoggerWithCtx_LevelOverride(t *testing.T) {
+	buf := &logtest.Buffer{}
+	tee := levelTee{&levelCore{Core: logtest.NewBuffCore(buf), level: zap.InfoLevel}}
+	logger := New(zap.New(tee))
+	ctx := WithLevelOverride(context.Background(), LevelOverride{Level: zapcore.DebugLevel})
+
+	logger.Ctx(context.Background()).Debug("global")
+	logger.Ctx(ctx).Debug("elevated")
+	logger.Debug("after")
+	lines := buf.Lines()
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], "elevated")
+
+	t.Run("component", func(t *testing.T) {
+		buf.Reset()
+		cl := Component("elevate")
+		cl.SetLogger(logger)
+		require.NoError(t, SetComponentLevel("elevate", "warn"))
+		t.Cleanup(func() {
+			_ = SetComponentLevel("elevate", "")
+		})
+		cl.Ctx(context.Background()).Info("component")
+		cl.Ctx(ctx).Debug("component elevated")
+		lines := buf.Lines()
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "component elevated")
+	})
+	t.Run("slog", func(t *testing.T) {
+		buf.Reset()
+		sl := slog.New(NewSlogHandler(logger))
+		sl.DebugContext(context.Background(), "global")
+		sl.DebugContext(ctx, "slog elevated")
+		lines := buf.Lines()
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "slog elevated")
+	})
+	t.Run("not config core", func(t *testing.T) {
+		buf.Reset()
+		logger := New(zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), buf, zap.InfoLevel)))
+		logger.Ctx(ctx).Debug("elevated")
+		assert.False(t, NewSlogHandler(logger).Enabled(ctx, slog.LevelDebug))
+		assert.Empty(t, buf.Lines())
+	})
+}
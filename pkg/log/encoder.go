@@ -0,0 +1,594 @@
+package log
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// LogfmtEncoding is the encoding name of LogfmtEncoder.
+	LogfmtEncoding = "logfmt"
+	// ECSEncoding is the encoding name of the Elastic Common Schema encoder, see NewECSEncoder.
+	ECSEncoding = "ecs"
+	// GELFEncoding is the encoding name of the Graylog Extended Log Format encoder, see NewGELFEncoder.
+	GELFEncoding = "gelf"
+
+	// ECSVersion is the version of Elastic Common Schema of the ecs encoding.
+	ECSVersion = "8.11.0"
+	// GELFVersion is the version of GELF of the gelf encoding.
+	GELFVersion = "1.1"
+)
+
+// encoderOptions are the options of Config for the registered encoders.
+type encoderOptions struct {
+	disableErrorVerbose bool
+	disableTimestamp    bool
+	traceIDKey          string
+}
+
+// encoderConfig is the encoderOptions of the Config building the loggers.
+var encoderConfig atomic.Pointer[encoderOptions]
+
+func init() {
+	currentOptions := func() encoderOptions {
+		if opts := encoderConfig.Load(); opts != nil {
+			return *opts
+		}
+		return encoderOptions{traceIDKey: TraceIDKey}
+	}
+	encoders := map[string]func(zapcore.EncoderConfig) (zapcore.Encoder, error){
+		LogfmtEncoding: func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			opts := currentOptions()
+			return NewLogfmtEncoder(cfg, opts.disableErrorVerbose, opts.disableTimestamp), nil
+		},
+		ECSEncoding: func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			opts := currentOptions()
+			return NewECSEncoder(cfg, opts.disableErrorVerbose, opts.disableTimestamp, opts.traceIDKey), nil
+		},
+		GELFEncoding: func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			opts := currentOptions()
+			return NewGELFEncoder(cfg, opts.disableErrorVerbose, opts.disableTimestamp, opts.traceIDKey), nil
+		},
+	}
+	for name, constructor := range encoders {
+		if err := zap.RegisterEncoder(name, constructor); err != nil {
+			panic(err)
+		}
+	}
+}
+
+var _ zapcore.Encoder = (*LogfmtEncoder)(nil)
+var _ zapcore.PrimitiveArrayEncoder = (*LogfmtEncoder)(nil)
+
+// LogfmtEncoder encodes the entries in logfmt such as:
+//
+//	ts=2024-01-02T15:04:05.000Z level=info caller=main.go:12 msg="hello world" trace_id=abc count=1
+//
+// The arrays, objects and reflected values are encoded in JSON and quoted, the keys in a namespace are prefixed by
+// the namespace and a dot.
+type LogfmtEncoder struct {
+	*zapcore.EncoderConfig
+	buf                 *buffer.Buffer
+	disableErrorVerbose bool
+	namespace           string
+	scratch             []byte
+}
+
+// NewLogfmtEncoder creates a LogfmtEncoder by the keys of config, the encoders of config are used if set.
+func NewLogfmtEncoder(config zapcore.EncoderConfig, disableErrorVerbose, disableTimestamp bool) *LogfmtEncoder {
+	cc := config
+	if disableTimestamp {
+		cc.TimeKey = ""
+	}
+	if cc.EncodeTime == nil {
+		cc.EncodeTime = zapcore.ISO8601TimeEncoder
+	}
+	if cc.EncodeLevel == nil {
+		cc.EncodeLevel = zapcore.LowercaseLevelEncoder
+	}
+	if cc.EncodeDuration == nil {
+		cc.EncodeDuration = zapcore.StringDurationEncoder
+	}
+	if cc.EncodeCaller == nil {
+		cc.EncodeCaller = zapcore.ShortCallerEncoder
+	}
+	if cc.LineEnding == "" {
+		cc.LineEnding = zapcore.DefaultLineEnding
+	}
+	return &LogfmtEncoder{
+		EncoderConfig:       &cc,
+		buf:                 _pool.Get(),
+		disableErrorVerbose: disableErrorVerbose,
+	}
+}
+
+func (enc *LogfmtEncoder) Clone() zapcore.Encoder {
+	clone := enc.cloned()
+	clone.buf.Write(enc.buf.Bytes())
+	return clone
+}
+
+func (enc *LogfmtEncoder) cloned() *LogfmtEncoder {
+	return &LogfmtEncoder{
+		EncoderConfig:       enc.EncoderConfig,
+		buf:                 _pool.Get(),
+		disableErrorVerbose: enc.disableErrorVerbose,
+		namespace:           enc.namespace,
+	}
+}
+
+func (enc *LogfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := enc.cloned()
+	if final.TimeKey != "" {
+		final.addEntryKey(final.TimeKey)
+		cur := final.buf.Len()
+		final.EncodeTime(ent.Time, final)
+		if cur == final.buf.Len() {
+			final.AppendInt64(ent.Time.UnixNano())
+		}
+	}
+	if final.LevelKey != "" {
+		final.addEntryKey(final.LevelKey)
+		cur := final.buf.Len()
+		final.EncodeLevel(ent.Level, final)
+		if cur == final.buf.Len() {
+			final.AppendString(ent.Level.String())
+		}
+	}
+	if ent.LoggerName != "" && final.NameKey != "" {
+		final.addEntryKey(final.NameKey)
+		cur := final.buf.Len()
+		if final.EncodeName != nil {
+			final.EncodeName(ent.LoggerName, final)
+		}
+		if cur == final.buf.Len() {
+			final.AppendString(ent.LoggerName)
+		}
+	}
+	if ent.Caller.Defined && final.CallerKey != "" {
+		final.addEntryKey(final.CallerKey)
+		cur := final.buf.Len()
+		final.EncodeCaller(ent.Caller, final)
+		if cur == final.buf.Len() {
+			final.AppendString(ent.Caller.String())
+		}
+	}
+	if final.MessageKey != "" {
+		final.addEntryKey(final.MessageKey)
+		final.AppendString(ent.Message)
+	}
+	if enc.buf.Len() > 0 {
+		final.buf.AppendByte(' ')
+		final.buf.Write(enc.buf.Bytes())
+	}
+	for _, f := range fields {
+		if f.Type == zapcore.ErrorType {
+			final.encodeError(f)
+			continue
+		}
+		f.AddTo(final)
+	}
+	if ent.Stack != "" && final.StacktraceKey != "" {
+		final.addEntryKey(final.StacktraceKey)
+		final.AppendString(ent.Stack)
+	}
+	final.buf.AppendString(final.LineEnding)
+	return final.buf, nil
+}
+
+func (enc *LogfmtEncoder) encodeError(f zapcore.Field) {
+	err, ok := f.Interface.(error)
+	if !ok || err == nil {
+		enc.AddString(f.Key, "<nil>")
+		return
+	}
+	basic := err.Error()
+	enc.AddString(f.Key, basic)
+	if enc.disableErrorVerbose {
+		return
+	}
+	if e, isFormatter := err.(fmt.Formatter); isFormatter {
+		if verbose := fmt.Sprintf("%+v", e); verbose != basic {
+			enc.AddString(f.Key+"Verbose", verbose)
+		}
+	}
+}
+
+// addEntryKey writes the key of the entry without the namespace.
+func (enc *LogfmtEncoder) addEntryKey(key string) {
+	if enc.buf.Len() > 0 {
+		enc.buf.AppendByte(' ')
+	}
+	enc.writeKey(key)
+	enc.buf.AppendByte('=')
+}
+
+func (enc *LogfmtEncoder) addKey(key string) {
+	if enc.buf.Len() > 0 {
+		enc.buf.AppendByte(' ')
+	}
+	if enc.namespace != "" {
+		enc.writeKey(enc.namespace)
+	}
+	enc.writeKey(key)
+	enc.buf.AppendByte('=')
+}
+
+// writeKey writes the key with the spaces, quotes, equal signs and control characters replaced by underscores.
+func (enc *LogfmtEncoder) writeKey(key string) {
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || unicode.IsControl(r) {
+			enc.buf.AppendByte('_')
+			continue
+		}
+		if r < utf8.RuneSelf {
+			enc.buf.AppendByte(byte(r))
+			continue
+		}
+		enc.buf.AppendString(string(r))
+	}
+}
+
+// writeValue writes the string value, quoted if it is empty or contains spaces, quotes, equal signs or
+// non-printable characters.
+func (enc *LogfmtEncoder) writeValue(s string) {
+	needQuote := s == ""
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || !unicode.IsPrint(r) {
+			needQuote = true
+			break
+		}
+	}
+	if !needQuote {
+		enc.buf.AppendString(s)
+		return
+	}
+	enc.scratch = strconv.AppendQuote(enc.scratch[:0], s)
+	enc.buf.Write(enc.scratch)
+}
+
+// addJSON writes the value in JSON as a string value.
+func (enc *LogfmtEncoder) addJSON(key string, v any) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	enc.addKey(key)
+	enc.writeValue(string(bs))
+	return nil
+}
+
+func (enc *LogfmtEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := m.AddArray(key, arr); err != nil {
+		return err
+	}
+	return enc.addJSON(key, m.Fields[key])
+}
+
+func (enc *LogfmtEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := obj.MarshalLogObject(m); err != nil {
+		return err
+	}
+	return enc.addJSON(key, m.Fields)
+}
+
+func (enc *LogfmtEncoder) AddReflected(key string, obj any) error {
+	return enc.addJSON(key, obj)
+}
+
+func (enc *LogfmtEncoder) OpenNamespace(key string) {
+	enc.namespace += key + "."
+}
+
+func (enc *LogfmtEncoder) AddBinary(key string, val []byte) {
+	enc.AddString(key, base64.StdEncoding.EncodeToString(val))
+}
+
+func (enc *LogfmtEncoder) AddByteString(key string, val []byte) {
+	enc.addKey(key)
+	enc.AppendByteString(val)
+}
+
+func (enc *LogfmtEncoder) AddBool(key string, val bool) {
+	enc.addKey(key)
+	enc.AppendBool(val)
+}
+
+func (enc *LogfmtEncoder) AddComplex128(key string, val complex128) {
+	enc.addKey(key)
+	enc.AppendComplex128(val)
+}
+
+func (enc *LogfmtEncoder) AddDuration(key string, val time.Duration) {
+	enc.addKey(key)
+	cur := enc.buf.Len()
+	enc.EncodeDuration(val, enc)
+	if cur == enc.buf.Len() {
+		enc.AppendInt64(int64(val))
+	}
+}
+
+func (enc *LogfmtEncoder) AddFloat64(key string, val float64) {
+	enc.addKey(key)
+	enc.AppendFloat64(val)
+}
+
+func (enc *LogfmtEncoder) AddInt64(key string, val int64) {
+	enc.addKey(key)
+	enc.AppendInt64(val)
+}
+
+func (enc *LogfmtEncoder) AddString(key, val string) {
+	enc.addKey(key)
+	enc.AppendString(val)
+}
+
+func (enc *LogfmtEncoder) AddTime(key string, val time.Time) {
+	enc.addKey(key)
+	cur := enc.buf.Len()
+	enc.EncodeTime(val, enc)
+	if cur == enc.buf.Len() {
+		enc.AppendInt64(val.UnixNano())
+	}
+}
+
+func (enc *LogfmtEncoder) AddUint64(key string, val uint64) {
+	enc.addKey(key)
+	enc.AppendUint64(val)
+}
+
+func (enc *LogfmtEncoder) AddComplex64(k string, v complex64) { enc.AddComplex128(k, complex128(v)) }
+func (enc *LogfmtEncoder) AddFloat32(k string, v float32)     { enc.AddFloat64(k, float64(v)) }
+func (enc *LogfmtEncoder) AddInt(k string, v int)             { enc.AddInt64(k, int64(v)) }
+func (enc *LogfmtEncoder) AddInt32(k string, v int32)         { enc.AddInt64(k, int64(v)) }
+func (enc *LogfmtEncoder) AddInt16(k string, v int16)         { enc.AddInt64(k, int64(v)) }
+func (enc *LogfmtEncoder) AddInt8(k string, v int8)           { enc.AddInt64(k, int64(v)) }
+func (enc *LogfmtEncoder) AddUint(k string, v uint)           { enc.AddUint64(k, uint64(v)) }
+func (enc *LogfmtEncoder) AddUint32(k string, v uint32)       { enc.AddUint64(k, uint64(v)) }
+func (enc *LogfmtEncoder) AddUint16(k string, v uint16)       { enc.AddUint64(k, uint64(v)) }
+func (enc *LogfmtEncoder) AddUint8(k string, v uint8)         { enc.AddUint64(k, uint64(v)) }
+func (enc *LogfmtEncoder) AddUintptr(k string, v uintptr)     { enc.AddUint64(k, uint64(v)) }
+
+// The Append methods write the single value of the encoders such as EncodeTime.
+
+func (enc *LogfmtEncoder) AppendBool(val bool)           { enc.buf.AppendBool(val) }
+func (enc *LogfmtEncoder) AppendByteString(val []byte)   { enc.writeValue(string(val)) }
+func (enc *LogfmtEncoder) AppendComplex64(val complex64) { enc.AppendComplex128(complex128(val)) }
+func (enc *LogfmtEncoder) AppendFloat64(val float64)     { enc.buf.AppendFloat(val, 64) }
+func (enc *LogfmtEncoder) AppendFloat32(val float32)     { enc.buf.AppendFloat(float64(val), 32) }
+func (enc *LogfmtEncoder) AppendInt(val int)             { enc.AppendInt64(int64(val)) }
+func (enc *LogfmtEncoder) AppendInt64(val int64)         { enc.buf.AppendInt(val) }
+func (enc *LogfmtEncoder) AppendInt32(val int32)         { enc.AppendInt64(int64(val)) }
+func (enc *LogfmtEncoder) AppendInt16(val int16)         { enc.AppendInt64(int64(val)) }
+func (enc *LogfmtEncoder) AppendInt8(val int8)           { enc.AppendInt64(int64(val)) }
+func (enc *LogfmtEncoder) AppendString(val string)       { enc.writeValue(val) }
+func (enc *LogfmtEncoder) AppendUint(val uint)           { enc.AppendUint64(uint64(val)) }
+func (enc *LogfmtEncoder) AppendUint64(val uint64)       { enc.buf.AppendUint(val) }
+func (enc *LogfmtEncoder) AppendUint32(val uint32)       { enc.AppendUint64(uint64(val)) }
+func (enc *LogfmtEncoder) AppendUint16(val uint16)       { enc.AppendUint64(uint64(val)) }
+func (enc *LogfmtEncoder) AppendUint8(val uint8)         { enc.AppendUint64(uint64(val)) }
+func (enc *LogfmtEncoder) AppendUintptr(val uintptr)     { enc.AppendUint64(uint64(val)) }
+func (enc *LogfmtEncoder) AppendComplex128(val complex128) {
+	enc.writeValue(strconv.FormatComplex(val, 'g', -1, 128))
+}
+
+// keyMapEncoder is a JSON encoder which renames the keys of the fields, it is the base of the ECS and GELF encoders.
+type keyMapEncoder struct {
+	zapcore.Encoder
+	mapKey              func(key string) string
+	disableErrorVerbose bool
+	stacktraceKey       string
+	// entryFields appends the fields of the entry such as the schema version.
+	entryFields func(ent zapcore.Entry, fields []zapcore.Field) []zapcore.Field
+}
+
+func (enc *keyMapEncoder) Clone() zapcore.Encoder {
+	clone := *enc
+	clone.Encoder = enc.Encoder.Clone()
+	return &clone
+}
+
+func (enc *keyMapEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	mapped := make([]zapcore.Field, 0, len(fields)+4)
+	if enc.entryFields != nil {
+		mapped = enc.entryFields(ent, mapped)
+	}
+	for _, f := range fields {
+		switch f.Type {
+		case zapcore.SkipType:
+			continue
+		case zapcore.ErrorType:
+			err, ok := f.Interface.(error)
+			if !ok || err == nil {
+				mapped = append(mapped, zap.String(enc.mapKey(f.Key), "<nil>"))
+				continue
+			}
+			basic := err.Error()
+			mapped = append(mapped, zap.String(enc.mapKey(f.Key), basic))
+			if enc.disableErrorVerbose {
+				continue
+			}
+			if e, isFormatter := err.(fmt.Formatter); isFormatter {
+				if verbose := fmt.Sprintf("%+v", e); verbose != basic {
+					mapped = append(mapped, zap.String(enc.mapKey(f.Key+"Verbose"), verbose))
+				}
+			}
+		default:
+			f.Key = enc.mapKey(f.Key)
+			mapped = append(mapped, f)
+		}
+	}
+	if ent.Stack != "" {
+		// the stack of the error takes the place of the stack of the entry if they have the same key.
+		for _, f := range mapped {
+			if f.Key == enc.stacktraceKey {
+				ent.Stack = ""
+				break
+			}
+		}
+	}
+	return enc.Encoder.EncodeEntry(ent, mapped)
+}
+
+func (enc *keyMapEncoder) AddArray(k string, v zapcore.ArrayMarshaler) error {
+	return enc.Encoder.AddArray(enc.mapKey(k), v)
+}
+func (enc *keyMapEncoder) AddObject(k string, v zapcore.ObjectMarshaler) error {
+	return enc.Encoder.AddObject(enc.mapKey(k), v)
+}
+func (enc *keyMapEncoder) AddReflected(k string, v any) error {
+	return enc.Encoder.AddReflected(enc.mapKey(k), v)
+}
+func (enc *keyMapEncoder) OpenNamespace(k string)       { enc.Encoder.OpenNamespace(enc.mapKey(k)) }
+func (enc *keyMapEncoder) AddBinary(k string, v []byte) { enc.Encoder.AddBinary(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddByteString(k string, v []byte) {
+	enc.Encoder.AddByteString(enc.mapKey(k), v)
+}
+func (enc *keyMapEncoder) AddBool(k string, v bool) { enc.Encoder.AddBool(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddComplex128(k string, v complex128) {
+	enc.Encoder.AddComplex128(enc.mapKey(k), v)
+}
+func (enc *keyMapEncoder) AddComplex64(k string, v complex64) {
+	enc.Encoder.AddComplex64(enc.mapKey(k), v)
+}
+func (enc *keyMapEncoder) AddDuration(k string, v time.Duration) {
+	enc.Encoder.AddDuration(enc.mapKey(k), v)
+}
+func (enc *keyMapEncoder) AddFloat64(k string, v float64) { enc.Encoder.AddFloat64(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddFloat32(k string, v float32) { enc.Encoder.AddFloat32(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddInt(k string, v int)         { enc.Encoder.AddInt(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddInt64(k string, v int64)     { enc.Encoder.AddInt64(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddInt32(k string, v int32)     { enc.Encoder.AddInt32(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddInt16(k string, v int16)     { enc.Encoder.AddInt16(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddInt8(k string, v int8)       { enc.Encoder.AddInt8(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddString(k, v string)          { enc.Encoder.AddString(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddTime(k string, v time.Time)  { enc.Encoder.AddTime(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddUint(k string, v uint)       { enc.Encoder.AddUint(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddUint64(k string, v uint64)   { enc.Encoder.AddUint64(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddUint32(k string, v uint32)   { enc.Encoder.AddUint32(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddUint16(k string, v uint16)   { enc.Encoder.AddUint16(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddUint8(k string, v uint8)     { enc.Encoder.AddUint8(enc.mapKey(k), v) }
+func (enc *keyMapEncoder) AddUintptr(k string, v uintptr) { enc.Encoder.AddUintptr(enc.mapKey(k), v) }
+
+// NewECSEncoder creates a JSON encoder in Elastic Common Schema: "@timestamp", "log.level", "log.logger",
+// "log.origin.file.name", "log.origin.file.line", "log.origin.function", "message" and "ecs.version". The trace id
+// field of traceIDKey is renamed to "trace.id", the "error" field to "error.message" and its verbose to
+// "error.stack_trace".
+func NewECSEncoder(config zapcore.EncoderConfig, disableErrorVerbose, disableTimestamp bool, traceIDKey string) zapcore.Encoder {
+	cc := zapcore.EncoderConfig{
+		TimeKey:        "@timestamp",
+		LevelKey:       "log.level",
+		NameKey:        "log.logger",
+		MessageKey:     "message",
+		StacktraceKey:  "error.stack_trace",
+		LineEnding:     config.LineEnding,
+		EncodeTime:     zapcore.TimeEncoderOfLayout("2006-01-02T15:04:05.000Z07:00"),
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeDuration: zapcore.NanosDurationEncoder,
+		EncodeName:     zapcore.FullNameEncoder,
+	}
+	if disableTimestamp {
+		cc.TimeKey = ""
+	}
+	withCaller := config.CallerKey != ""
+	return &keyMapEncoder{
+		Encoder:             zapcore.NewJSONEncoder(cc),
+		disableErrorVerbose: disableErrorVerbose,
+		stacktraceKey:       cc.StacktraceKey,
+		mapKey: func(key string) string {
+			switch key {
+			case traceIDKey:
+				return "trace.id"
+			case "error":
+				return "error.message"
+			case "errorVerbose":
+				return "error.stack_trace"
+			}
+			return key
+		},
+		entryFields: func(ent zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+			fields = append(fields, zap.String("ecs.version", ECSVersion))
+			if withCaller && ent.Caller.Defined {
+				fields = append(fields,
+					zap.String("log.origin.file.name", ent.Caller.File),
+					zap.Int("log.origin.file.line", ent.Caller.Line),
+				)
+				if ent.Caller.Function != "" {
+					fields = append(fields, zap.String("log.origin.function", ent.Caller.Function))
+				}
+			}
+			return fields
+		},
+	}
+}
+
+// NewGELFEncoder creates a JSON encoder in GELF: "version", "host", "short_message", "full_message" for the
+// stacktrace, "timestamp" in seconds and "level" in syslog severity. The other fields are additional fields prefixed
+// by an underscore, such as "_trace_id" for the field of traceIDKey, "_logger" and "_caller".
+//
+// GELF over TCP requires the null byte as the line ending, it can be set by encoderConfig.lineEnding.
+func NewGELFEncoder(config zapcore.EncoderConfig, disableErrorVerbose, disableTimestamp bool, traceIDKey string) zapcore.Encoder {
+	cc := zapcore.EncoderConfig{
+		TimeKey:        "timestamp",
+		LevelKey:       "level",
+		NameKey:        "_logger",
+		MessageKey:     "short_message",
+		StacktraceKey:  "full_message",
+		LineEnding:     config.LineEnding,
+		EncodeTime:     zapcore.EpochTimeEncoder,
+		EncodeLevel:    gelfLevelEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeName:     zapcore.FullNameEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+	if config.CallerKey != "" {
+		cc.CallerKey = "_caller"
+	}
+	if disableTimestamp {
+		cc.TimeKey = ""
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return &keyMapEncoder{
+		Encoder:             zapcore.NewJSONEncoder(cc),
+		disableErrorVerbose: disableErrorVerbose,
+		stacktraceKey:       cc.StacktraceKey,
+		mapKey: func(key string) string {
+			if key == traceIDKey {
+				return "_trace_id"
+			}
+			if strings.HasPrefix(key, "_") {
+				return key
+			}
+			return "_" + key
+		},
+		entryFields: func(_ zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+			return append(fields, zap.String("version", GELFVersion), zap.String("host", host))
+		},
+	}
+}
+
+// gelfLevelEncoder serializes a Level to the syslog severity.
+func gelfLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	severity, ok := syslogSeverities[l.String()]
+	if !ok {
+		severity = syslogSeverities["info"]
+	}
+	enc.AppendInt(severity)
+}
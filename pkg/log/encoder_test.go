@@ -0,0 +1,186 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// verboseError is an error with the verbose format like github.com/pkg/errors.
+type verboseError struct{}
+
+func (verboseError) Error() string { return "broken" }
+
+func (e verboseError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		_, _ = io.WriteString(s, "broken\nmain.go:10")
+		return
+	}
+	_, _ = io.WriteString(s, e.Error())
+}
+
+var testEntry = zapcore.Entry{
+	Level:      zapcore.ErrorLevel,
+	Time:       time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+	LoggerName: "app",
+	Message:    "hello world",
+	Caller:     zapcore.EntryCaller{Defined: true, File: "/src/main.go", Line: 12, Function: "main.main"},
+}
+
+func encodeJSON(t *testing.T, enc zapcore.Encoder, ent zapcore.Entry, fields ...zap.Field) map[string]any {
+	buf, err := enc.EncodeEntry(ent, fields)
+	require.NoError(t, err)
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got), buf.String())
+	return got
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	cfg := zap.NewProductionEncoderConfig()
+	cfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	t.Run("entry", func(t *testing.T) {
+		enc := NewLogfmtEncoder(cfg, false, false)
+		ctx := enc.Clone()
+		ctx.AddString(TraceIDKey, "abc")
+		buf, err := ctx.EncodeEntry(testEntry, []zap.Field{
+			zap.Int("count", 1),
+			zap.String("empty", ""),
+			zap.String("quote", `say "hi"`),
+			zap.Strings("list", []string{"a", "b"}),
+			zap.Any("map", map[string]int{"a": 1}),
+			zap.Duration("latency", time.Second),
+			zap.Namespace("ns"),
+			zap.Bool("ok", true),
+			zap.Error(verboseError{}),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `ts=2024-01-02T15:04:05.000Z level=error logger=app caller=src/main.go:12 msg="hello world" `+
+			`trace_id=abc count=1 empty="" quote="say \"hi\"" list="[\"a\",\"b\"]" map="{\"a\":1}" latency=1 `+
+			`ns.ok=true ns.error=broken ns.errorVerbose="broken\nmain.go:10"`+"\n", buf.String())
+	})
+	t.Run("disable", func(t *testing.T) {
+		enc := NewLogfmtEncoder(cfg, true, true)
+		ent := testEntry
+		ent.Caller = zapcore.EntryCaller{}
+		ent.Stack = "stack"
+		buf, err := enc.EncodeEntry(ent, []zap.Field{zap.Error(verboseError{})})
+		require.NoError(t, err)
+		assert.Equal(t, `level=error logger=app msg="hello world" error=broken stacktrace=stack`+"\n", buf.String())
+	})
+}
+
+func TestECSEncoder(t *testing.T) {
+	t.Run("entry", func(t *testing.T) {
+		enc := NewECSEncoder(zap.NewProductionEncoderConfig(), false, false, "tid")
+		ctx := enc.Clone()
+		ctx.AddString("tid", "abc")
+		got := encodeJSON(t, ctx, testEntry, zap.Error(verboseError{}), zap.Int("count", 1))
+		assert.Equal(t, map[string]any{
+			"@timestamp":           "2024-01-02T15:04:05.000Z",
+			"log.level":            "error",
+			"log.logger":           "app",
+			"log.origin.file.name": "/src/main.go",
+			"log.origin.file.line": float64(12),
+			"log.origin.function":  "main.main",
+			"message":              "hello world",
+			"ecs.version":          ECSVersion,
+			"trace.id":             "abc",
+			"error.message":        "broken",
+			"error.stack_trace":    "broken\nmain.go:10",
+			"count":                float64(1),
+		}, got)
+	})
+	t.Run("disable", func(t *testing.T) {
+		cfg := zap.NewProductionEncoderConfig()
+		cfg.CallerKey = ""
+		enc := NewECSEncoder(cfg, true, true, TraceIDKey)
+		ent := testEntry
+		ent.Stack = "stack"
+		got := encodeJSON(t, enc, ent, zap.Error(verboseError{}), zap.String(TraceIDKey, "abc"))
+		assert.NotContains(t, got, "@timestamp")
+		assert.NotContains(t, got, "log.origin.file.name")
+		assert.Equal(t, "broken", got["error.message"])
+		assert.Equal(t, "stack", got["error.stack_trace"], "should use the stack of the entry")
+		assert.Equal(t, "abc", got["trace.id"])
+	})
+}
+
+func TestGELFEncoder(t *testing.T) {
+	enc := NewGELFEncoder(zap.NewProductionEncoderConfig(), true, false, TraceIDKey)
+	ctx := enc.Clone()
+	ctx.AddString("user", "u1")
+	ent := testEntry
+	ent.Stack = "stack"
+	got := encodeJSON(t, ctx, ent, zap.String(TraceIDKey, "abc"), zap.Error(verboseError{}), zap.Int("_count", 1))
+	host, _ := os.Hostname()
+	assert.Equal(t, map[string]any{
+		"version":       GELFVersion,
+		"host":          host,
+		"timestamp":     float64(testEntry.Time.Unix()),
+		"level":         float64(3),
+		"short_message": "hello world",
+		"full_message":  "stack",
+		"_logger":       "app",
+		"_caller":       "src/main.go:12",
+		"_user":         "u1",
+		"_trace_id":     "abc",
+		"_error":        "broken",
+		"_count":        float64(1),
+	}, got)
+}
+
+func TestConfig_Encoding(t *testing.T) {
+	dir := t.TempDir()
+	cfg := conf.NewFromStringMap(map[string]any{
+		"disableSampling":  true,
+		"disableTimestamp": true,
+		"traceIDKey":       "traceId",
+		"cores": []any{
+			map[string]any{"level": "info", "encoding": LogfmtEncoding, "outputPaths": []any{"logfmt.log"}, "disableCaller": true},
+			map[string]any{"level": "info", "encoding": ECSEncoding, "outputPaths": []any{"ecs.log"}},
+			map[string]any{"level": "info", "encoding": GELFEncoding, "outputPaths": []any{"gelf.log"}},
+		},
+	})
+	cfg.SetBaseDir(dir)
+	config, err := NewConfig(cfg)
+	require.NoError(t, err)
+	zl, err := config.BuildZap()
+	require.NoError(t, err)
+	zl.Info("encoding", zap.String("traceId", "abc"))
+	require.NoError(t, zl.Sync())
+
+	read := func(name string) string {
+		bs, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return strings.TrimSpace(string(bs))
+	}
+	assert.Equal(t, "level=info msg=encoding traceId=abc", read("logfmt.log"))
+	var ecs, gelf map[string]any
+	require.NoError(t, json.Unmarshal([]byte(read("ecs.log")), &ecs))
+	assert.Equal(t, "abc", ecs["trace.id"])
+	assert.NotContains(t, ecs, "@timestamp")
+	require.NoError(t, json.Unmarshal([]byte(read("gelf.log")), &gelf))
+	assert.Equal(t, "abc", gelf["_trace_id"])
+	assert.NotContains(t, gelf, "timestamp")
+}
+
+func BenchmarkLogfmtEncoder(b *testing.B) {
+	enc := NewLogfmtEncoder(zap.NewProductionEncoderConfig(), false, false)
+	fields := []zap.Field{zap.String("key", "value with space"), zap.Int("count", 1)}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf, _ := enc.EncodeEntry(testEntry, fields)
+		buf.Free()
+	}
+}
@@ -0,0 +1,302 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// componentLevel is the level of a component, the component follows the levels of the cores if it is not set.
+type componentLevel struct {
+	set   atomic.Bool
+	level zap.AtomicLevel
+}
+
+func newComponentLevel() *componentLevel {
+	return &componentLevel{level: zap.NewAtomicLevel()}
+}
+
+// enabler returns the level of component, nil if not set.
+func (cl *componentLevel) enabler() zapcore.LevelEnabler {
+	if cl == nil || !cl.set.Load() {
+		return nil
+	}
+	return cl.level
+}
+
+func (cl *componentLevel) String() string {
+	if !cl.set.Load() {
+		return ""
+	}
+	return cl.level.String()
+}
+
+// levelCore is a core built by Config, the inner core accepts all levels and levelCore filters the entries by
+// the configured level of the core and the level of the component, the override level of a request enables
+// the entries at or above it in addition.
+type levelCore struct {
+	zapcore.Core
+	level     zapcore.LevelEnabler
+	component *componentLevel
+	// follow uses the level of the component instead of the level of the core, see Config.Components.
+	follow bool
+	// override is the level override of a request, nil means not overridden.
+	override zapcore.LevelEnabler
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	if c.override != nil && c.override.Enabled(lvl) {
+		return true
+	}
+	if cl := c.component.enabler(); cl != nil {
+		if c.follow {
+			return cl.Enabled(lvl)
+		}
+		if !cl.Enabled(lvl) {
+			return false
+		}
+	}
+	return c.level.Enabled(lvl)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level, component: c.component, follow: c.follow,
+		override: c.override}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// levelTee duplicates the entries into the levelCores like zapcore.NewTee, the level of component bounds
+// the levels of the cores.
+type levelTee []*levelCore
+
+func (t levelTee) withComponent(cl *componentLevel) levelTee {
+	res := make(levelTee, len(t))
+	for i, c := range t {
+		res[i] = &levelCore{Core: c.Core, level: c.level, component: cl, follow: c.follow, override: c.override}
+	}
+	return res
+}
+
+// withOverride returns the cores enabling the level in addition, see LevelOverride.
+func (t levelTee) withOverride(lvl zapcore.Level) levelTee {
+	res := make(levelTee, len(t))
+	for i, c := range t {
+		res[i] = &levelCore{Core: c.Core, level: c.level, component: c.component, follow: c.follow, override: lvl}
+	}
+	return res
+}
+
+func (t levelTee) Enabled(lvl zapcore.Level) bool {
+	for _, c := range t {
+		if c.Enabled(lvl) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t levelTee) With(fields []zapcore.Field) zapcore.Core {
+	res := make(levelTee, len(t))
+	for i, c := range t {
+		res[i] = c.With(fields).(*levelCore)
+	}
+	return res
+}
+
+func (t levelTee) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	for _, c := range t {
+		ce = c.Check(ent, ce)
+	}
+	return ce
+}
+
+func (t levelTee) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var errs []error
+	for _, c := range t {
+		if c.Enabled(ent.Level) {
+			errs = append(errs, c.Write(ent, fields))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t levelTee) Sync() error {
+	var errs []error
+	for _, c := range t {
+		errs = append(errs, c.Sync())
+	}
+	return errors.Join(errs...)
+}
+
+// componentCore filters the entries by the level of component for the cores not built by Config,
+// it can only raise the level of the cores.
+type componentCore struct {
+	zapcore.Core
+	component *componentLevel
+}
+
+func (c *componentCore) Enabled(lvl zapcore.Level) bool {
+	if cl := c.component.enabler(); cl != nil && !cl.Enabled(lvl) {
+		return false
+	}
+	return c.Core.Enabled(lvl)
+}
+
+func (c *componentCore) With(fields []zapcore.Field) zapcore.Core {
+	return &componentCore{Core: c.Core.With(fields), component: c.component}
+}
+
+func (c *componentCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if cl := c.component.enabler(); cl != nil && !cl.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// wrapComponentCore applies the level of component to the core.
+func wrapComponentCore(core zapcore.Core, cl *componentLevel) zapcore.Core {
+	if t, ok := core.(levelTee); ok {
+		return t.withComponent(cl)
+	}
+	return &componentCore{Core: core, component: cl}
+}
+
+// getComponentLevel returns the level of the component, caller must hold compoenetMu.
+func getComponentLevel(name string) *componentLevel {
+	cl, ok := componentLevels[name]
+	if !ok {
+		cl = newComponentLevel()
+		componentLevels[name] = cl
+	}
+	return cl
+}
+
+// SetComponentLevel sets the level of the component logger at runtime, the level is a bound on top of the levels of
+// the cores, and empty level resets it to follow the cores. It can be called before the component is created.
+//
+// The level can only raise the levels of the cores, so that a debug component does not flood the error-only cores.
+// For the logger built by Config, the cores with "followComponents: true" use the level of the component instead,
+// so a component can log debug entries to such a core while the core is at info level.
+func SetComponentLevel(name, level string) error {
+	var lvl zapcore.Level
+	if level != "" {
+		var err error
+		if lvl, err = zapcore.ParseLevel(level); err != nil {
+			return err
+		}
+	}
+	compoenetMu.Lock()
+	cl := getComponentLevel(name)
+	compoenetMu.Unlock()
+	if level == "" {
+		cl.set.Store(false)
+		return nil
+	}
+	cl.level.SetLevel(lvl)
+	cl.set.Store(true)
+	return nil
+}
+
+// ComponentLevels returns the levels of the created components and the components with a level, the level is empty
+// if the component follows the levels of the cores.
+func ComponentLevels() map[string]string {
+	compoenetMu.RLock()
+	defer compoenetMu.RUnlock()
+	res := make(map[string]string, len(componentLevels))
+	for name := range components {
+		res[name] = ""
+	}
+	for name, cl := range componentLevels {
+		res[name] = cl.String()
+	}
+	return res
+}
+
+type componentLevelPayload struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+// ComponentLevelHandler returns a http.Handler to get or change the levels of components, it can be mounted
+// on any path, such as `group.Any("/loglevel", gin.WrapH(log.ComponentLevelHandler()))`:
+//
+//   - GET: returns the levels of all components, or the level of the component by query `name`.
+//   - PUT or POST: changes the level by query or form `name` and `level`, or JSON body `{"name":"grpc","level":"debug"}`,
+//     empty level resets the component to follow the cores.
+func ComponentLevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			levels := ComponentLevels()
+			name := r.URL.Query().Get("name")
+			if name == "" {
+				writeLevelJSON(w, http.StatusOK, levels)
+				return
+			}
+			level, ok := levels[name]
+			if !ok {
+				writeLevelJSON(w, http.StatusNotFound, map[string]string{"error": "component not found: " + name})
+				return
+			}
+			writeLevelJSON(w, http.StatusOK, componentLevelPayload{Name: name, Level: level})
+		case http.MethodPut, http.MethodPost:
+			payload := componentLevelPayload{Name: r.FormValue("name"), Level: r.FormValue("level")}
+			if payload.Name == "" && r.Body != nil {
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					writeLevelJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+					return
+				}
+			}
+			if payload.Name == "" {
+				writeLevelJSON(w, http.StatusBadRequest, map[string]string{"error": "missing component name"})
+				return
+			}
+			if err := SetComponentLevel(payload.Name, payload.Level); err != nil {
+				writeLevelJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			Global().Logger(WithOriginalLogger()).Info("component log level changed",
+				zap.String(ComponentKey, payload.Name), zap.String("level", payload.Level))
+			writeLevelJSON(w, http.StatusOK, payload)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			writeLevelJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		}
+	})
+}
+
+func writeLevelJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// applyComponentLevels sets the levels of components in the configuration, and resets the components configured
+// by the previous configuration but not in the current one.
+func applyComponentLevels(prev []string, comps map[string]ComponentConfig) ([]string, error) {
+	names := make([]string, 0, len(comps))
+	for name, cc := range comps {
+		if err := SetComponentLevel(name, cc.Level); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range prev {
+		if _, ok := comps[name]; !ok {
+			_ = SetComponentLevel(name, "")
+		}
+	}
+	return names, nil
+}
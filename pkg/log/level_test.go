@@ -0,0 +1,148 @@
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/test/logtest"
+)
+
+func TestComponentLevel(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "level.log")
+	errFile := filepath.Join(t.TempDir(), "error.log")
+	cfg := conf.NewFromStringMap(map[string]any{
+		"disableSampling": true,
+		"cores": []any{
+			map[string]any{"level": "info", "outputPaths": []any{file}, "followComponents": true},
+			map[string]any{"level": "error", "outputPaths": []any{errFile}},
+		},
+		"components": map[string]any{
+			"level-a": map[string]any{"level": "debug"},
+			"level-c": map[string]any{"level": "error"},
+		},
+	})
+	logger := NewFromConf(cfg)
+	a, b := Component("level-a"), Component("level-b")
+	a.SetLogger(logger)
+	b.SetLogger(logger)
+	lines := func() string {
+		bs, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(file, 0))
+		return string(bs)
+	}
+
+	a.Debug("a-debug")
+	b.Debug("b-debug")
+	b.Info("b-info")
+	got := lines()
+	assert.Contains(t, got, "a-debug")
+	assert.NotContains(t, got, "b-debug")
+	assert.Contains(t, got, "b-info")
+	assert.Equal(t, map[string]string{"level-a": "debug", "level-b": "", "level-c": "error"}, filterLevels("level-"))
+
+	t.Run("bound", func(t *testing.T) {
+		a.Info("a-info")
+		a.Error("a-error")
+		bs, err := os.ReadFile(errFile)
+		require.NoError(t, err)
+		assert.NotContains(t, string(bs), "a-debug", "should not lower the level of the core not following components")
+		assert.NotContains(t, string(bs), "a-info")
+		assert.Contains(t, string(bs), "a-error")
+		c := Component("level-c")
+		c.SetLogger(logger)
+		c.Warn("c-warn")
+		assert.NotContains(t, lines(), "c-warn", "should raise the level of the core")
+	})
+
+	t.Run("runtime", func(t *testing.T) {
+		require.NoError(t, SetComponentLevel("level-a", "error"))
+		require.NoError(t, SetComponentLevel("level-b", "debug"))
+		a.Warn("a-warn")
+		b.Debug("b-debug")
+		got := lines()
+		assert.NotContains(t, got, "a-warn")
+		assert.Contains(t, got, "b-debug")
+		require.NoError(t, logger.SetLevel("error"))
+		b.Info("b-info")
+		assert.Contains(t, lines(), "b-info", "should not be affected by the level of cores")
+		require.NoError(t, logger.SetLevel("info"))
+
+		require.NoError(t, SetComponentLevel("level-b", ""))
+		b.Debug("b-debug")
+		assert.Empty(t, lines())
+		assert.Error(t, SetComponentLevel("level-b", "wrong"))
+	})
+	t.Run("reload", func(t *testing.T) {
+		require.NoError(t, logger.ReloadLevels(conf.NewFromStringMap(map[string]any{
+			"cores":      []any{map[string]any{"level": "info"}},
+			"components": map[string]any{"level-b": map[string]any{"level": "warn"}},
+		})))
+		a.Debug("a-debug")
+		a.Info("a-info")
+		b.Info("b-info")
+		got := lines()
+		assert.NotContains(t, got, "a-debug")
+		assert.Contains(t, got, "a-info")
+		assert.NotContains(t, got, "b-info")
+		assert.Equal(t, map[string]string{"level-a": "", "level-b": "warn", "level-c": ""}, filterLevels("level-"))
+		assert.Error(t, logger.ReloadLevels(conf.NewFromStringMap(map[string]any{
+			"cores":      []any{map[string]any{"level": "info"}},
+			"components": map[string]any{"level-b": map[string]any{"level": "wrong"}},
+		})))
+	})
+	t.Run("not config logger", func(t *testing.T) {
+		logdata := &logtest.Buffer{}
+		c := Component("level-d")
+		c.SetLogger(&Logger{Logger: logtest.NewBuffLogger(logdata)})
+		require.NoError(t, SetComponentLevel("level-d", "warn"))
+		c.Info("d-info")
+		c.Warn("d-warn")
+		assert.Len(t, logdata.Lines(), 1)
+	})
+}
+
+func filterLevels(prefix string) map[string]string {
+	res := make(map[string]string)
+	for name, level := range ComponentLevels() {
+		if strings.HasPrefix(name, prefix) && name != "level-d" {
+			res[name] = level
+		}
+	}
+	return res
+}
+
+func TestComponentLevelHandler(t *testing.T) {
+	Component("level-handler")
+	handler := ComponentLevelHandler()
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body == "" {
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	w := do(http.MethodPut, "/?name=level-handler&level=debug", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = do(http.MethodGet, "/?name=level-handler", "")
+	assert.JSONEq(t, `{"name":"level-handler","level":"debug"}`, w.Body.String())
+	w = do(http.MethodPost, "/", `{"name":"level-handler","level":""}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = do(http.MethodGet, "/", "")
+	assert.Contains(t, w.Body.String(), `"level-handler":""`)
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/?name=level-none", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/?name=level-handler&level=wrong", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/", `{"level":"info"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/", `x`).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodDelete, "/", "").Code)
+}
@@ -16,6 +16,7 @@ var (
 	globalComponent ComponentLogger
 	compoenetMu     sync.RWMutex
 	components      = map[string]*component{}
+	componentLevels = map[string]*componentLevel{}
 )
 
 func init() {
@@ -54,6 +55,12 @@ type Logger struct {
 	contextLogger ContextLogger
 	// level of zap cores
 	logLevels []zap.AtomicLevel
+	// componentNames are the components whose level is set by the configuration.
+	componentNames []string
+	// redactor is the Redactor of the cores, nil if the redaction is not configured.
+	redactor *Redactor
+	// asyncWriters are the writers of the async cores, they are closed by Close.
+	asyncWriters []*AsyncWriter
 }
 
 // New create an Instance from zap
@@ -104,7 +111,7 @@ func (l *Logger) Apply(cfg *conf.Configuration) {
 	if err != nil {
 		panic(fmt.Errorf("apply log configuration err:%w", err))
 	}
-	zl, err := config.BuildZap(zap.AddCallerSkip(config.callerSkip))
+	zl, err := config.BuildZap(zap.AddCallerSkip(config.CallerSkip))
 	if err != nil {
 		panic(fmt.Errorf("apply log configuration err:%w", err))
 	}
@@ -112,14 +119,31 @@ func (l *Logger) Apply(cfg *conf.Configuration) {
 	for i, zc := range config.ZapConfigs {
 		l.logLevels[i] = zc.Level
 	}
+	if l.componentNames, err = applyComponentLevels(l.componentNames, config.Components); err != nil {
+		panic(fmt.Errorf("apply log configuration err:%w", err))
+	}
 	l.Logger = zl
+	l.redactor = config.redactor
+	// the previous async writers are closed, the loggers derived from them write to the outputs directly.
+	_ = closeAsyncWriters(l.asyncWriters)
+	l.asyncWriters = config.asyncWriters
+	SetContextFields(config.ContextFields)
 	l.WithTraceID = config.WithTraceID
 	if config.TraceIDKey != "" {
 		l.TraceIDKey = config.TraceIDKey
 	}
 }
 
-// SetLevel set log level to zap core level.
+// Close flushes the buffered entries and stops the flushing goroutines of the async cores, the later entries are
+// written to the outputs directly. It is called when the App stops and the Logger is rebuilt by Apply.
+func (l *Logger) Close() error {
+	ws := l.asyncWriters
+	l.asyncWriters = nil
+	return closeAsyncWriters(ws)
+}
+
+// SetLevel set log level to zap core level, the components with their own level are not affected,
+// see SetComponentLevel.
 func (l *Logger) SetLevel(lvl string) error {
 	level, err := zapcore.ParseLevel(lvl)
 	if err != nil {
@@ -131,6 +155,23 @@ func (l *Logger) SetLevel(lvl string) error {
 	return nil
 }
 
+// ReloadLevels applies the levels of the cores and the components in the configuration to the logger at runtime,
+// the other settings of the cores take effect after restarting.
+func (l *Logger) ReloadLevels(cfg *conf.Configuration) error {
+	config, err := NewConfig(cfg)
+	if err != nil {
+		return err
+	}
+	for i, zc := range config.ZapConfigs {
+		if i >= len(l.logLevels) {
+			break
+		}
+		l.logLevels[i].SetLevel(zc.Level.Level())
+	}
+	l.componentNames, err = applyComponentLevels(l.componentNames, config.Components)
+	return err
+}
+
 // With creates a child logger and adds structured context to it. Fields added
 // to the child don't affect the parent, and vice versa.
 func (l *Logger) With(fields ...zap.Field) *Logger {
@@ -152,6 +193,25 @@ func (l *Logger) Operator() *zap.Logger {
 	return l.Logger
 }
 
+// Redactor returns the Redactor configured by Config.Redact, or DefaultRedactor if not configured.
+// The component loggers such as the access logs use it to mask the sensitive fields.
+func (l *Logger) Redactor() *Redactor {
+	if l.redactor != nil {
+		return l.redactor
+	}
+	return DefaultRedactor()
+}
+
+// RedactFields masks the fields by DefaultRedactor if Config.Redact is not configured, it is used by the access logs
+// to mask the sensitive values by default. If configured, the fields are returned as is, because the cores mask them
+// before encoding.
+func (l *Logger) RedactFields(fields []zap.Field) []zap.Field {
+	if l.redactor != nil {
+		return fields
+	}
+	return DefaultRedactor().Fields(fields)
+}
+
 // ContextLogger return contextLogger field
 func (l *Logger) ContextLogger() ContextLogger {
 	return l.contextLogger
@@ -535,3 +535,20 @@ log:
 		logger.Warn("TestLogger_SetLevel")
 	})
 }
+
+func TestLogger_ReloadLevels(t *testing.T) {
+	cfg := conf.NewFromBytes([]byte(`
+cores:
+  - level: debug
+  - level: info
+`)).Load()
+	logger := NewFromConf(cfg)
+	require.NoError(t, logger.ReloadLevels(conf.NewFromBytes([]byte(`
+cores:
+  - level: warn
+  - level: error
+`)).Load()))
+	assert.Equal(t, zap.WarnLevel, logger.logLevels[0].Level())
+	assert.Equal(t, zap.ErrorLevel, logger.logLevels[1].Level())
+	assert.Error(t, logger.ReloadLevels(conf.NewFromBytes([]byte(`cores: "wrong"`)).Load()))
+}
@@ -0,0 +1,588 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultNetBackoff        = time.Second
+	defaultNetMaxBackoff     = 30 * time.Second
+	defaultNetTimeout        = 5 * time.Second
+	defaultHTTPBatchSize     = 100
+	defaultHTTPFlushInterval = time.Second
+)
+
+// netSchemePrefix prefixes the schemes of the network sinks registered to zap, so that the generic schemes such as
+// "http" are not taken from the other users of zap. The output paths such as "tcp://host:port" are converted to them.
+const netSchemePrefix = "woocoo-"
+
+// netSchemes are the schemes of the network sinks, the output paths of them are not converted to files.
+var netSchemes = map[string]func(*url.URL) (zap.Sink, error){
+	"tcp":    newConnSink,
+	"udp":    newConnSink,
+	"syslog": newSyslogSink,
+	"http":   newHTTPSink,
+	"https":  newHTTPSink,
+}
+
+func init() {
+	for scheme, factory := range netSchemes {
+		if err := zap.RegisterSink(netSchemePrefix+scheme, factory); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// isNetPath reports whether the output path is the URL of a network sink.
+func isNetPath(path string) bool {
+	scheme, _, ok := strings.Cut(path, "://")
+	_, isNet := netSchemes[strings.ToLower(scheme)]
+	return ok && isNet
+}
+
+// netURL returns the url with the scheme of the output path, such as "tcp" for "woocoo-tcp".
+func netURL(u *url.URL) *url.URL {
+	nu := *u
+	nu.Scheme = strings.TrimPrefix(strings.ToLower(u.Scheme), netSchemePrefix)
+	return &nu
+}
+
+// resolveNetPath resolves the relative fallback file of the network sink by the base dir, and converts the scheme to
+// the registered one.
+func resolveNetPath(path, base string) (string, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("can't parse %q as a URL: %v", path, err)
+	}
+	query := u.Query()
+	if fb := query.Get("fallback"); fb != "" && !filepath.IsAbs(fb) {
+		query.Set("fallback", filepath.Join(base, fb))
+		u.RawQuery = query.Encode()
+	}
+	u.Scheme = netSchemePrefix + strings.ToLower(u.Scheme)
+	return u.String(), nil
+}
+
+// netSinkOptions are the common query parameters of the network sinks:
+//
+//   - fallback: the file to write while the remote end is down, the relative path is based on the base dir.
+//   - backoff: the initial interval of reconnecting, default 1s, doubled by every failure.
+//   - maxBackoff: the max interval of reconnecting, default 30s.
+//   - timeout: the timeout of dialing and pushing, default 5s.
+type netSinkOptions struct {
+	fallback   string
+	backoff    time.Duration
+	maxBackoff time.Duration
+	timeout    time.Duration
+}
+
+// parseNetSinkOptions reads and removes the common query parameters from u.
+func parseNetSinkOptions(u *url.URL) (opts netSinkOptions, query url.Values, err error) {
+	query = u.Query()
+	opts = netSinkOptions{
+		fallback:   query.Get("fallback"),
+		backoff:    defaultNetBackoff,
+		maxBackoff: defaultNetMaxBackoff,
+		timeout:    defaultNetTimeout,
+	}
+	for key, dst := range map[string]*time.Duration{
+		"backoff": &opts.backoff, "maxBackoff": &opts.maxBackoff, "timeout": &opts.timeout,
+	} {
+		if v := query.Get(key); v != "" {
+			if *dst, err = time.ParseDuration(v); err != nil {
+				return opts, nil, fmt.Errorf("invalid %s of %s: %w", key, u.Redacted(), err)
+			}
+		}
+		query.Del(key)
+	}
+	query.Del("fallback")
+	return opts, query, nil
+}
+
+// backoff is the exponential interval of reconnecting.
+type backoff struct {
+	min, max time.Duration
+	cur      time.Duration
+	next     time.Time
+}
+
+// ready reports whether it is time to try again.
+func (b *backoff) ready(now time.Time) bool {
+	return !now.Before(b.next)
+}
+
+func (b *backoff) fail(now time.Time) {
+	if b.cur == 0 {
+		b.cur = b.min
+	} else if b.cur *= 2; b.cur > b.max {
+		b.cur = b.max
+	}
+	b.next = now.Add(b.cur)
+}
+
+func (b *backoff) reset() {
+	b.cur, b.next = 0, time.Time{}
+}
+
+// fallbackFile is the local file written while the remote end is down, it is opened at the first writing.
+type fallbackFile struct {
+	path string
+	file *os.File
+}
+
+func (f *fallbackFile) Write(p []byte) (int, error) {
+	if f.path == "" {
+		return 0, errors.New("no fallback file")
+	}
+	if f.file == nil {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+			return 0, err
+		}
+		file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return 0, err
+		}
+		f.file = file
+	}
+	return f.file.Write(p)
+}
+
+func (f *fallbackFile) Sync() error {
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+func (f *fallbackFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// connSink writes the entries to a TCP or UDP connection, the entries are written to the fallback file while
+// disconnected. The connection is dialed when the sink is created, and redialed with backoff in background after
+// failures, so that the writing never waits for the dialing.
+type connSink struct {
+	network string
+	addr    string
+	timeout time.Duration
+	// frame converts an entry to the bytes to send.
+	frame func([]byte) []byte
+
+	mu       sync.Mutex
+	conn     net.Conn
+	dialing  bool
+	closed   bool
+	backoff  backoff
+	fallback fallbackFile
+	now      func() time.Time
+}
+
+func newConnSinkByOptions(network, addr string, opts netSinkOptions) *connSink {
+	s := &connSink{
+		network:  network,
+		addr:     addr,
+		timeout:  opts.timeout,
+		backoff:  backoff{min: opts.backoff, max: opts.maxBackoff},
+		fallback: fallbackFile{path: opts.fallback},
+		now:      time.Now,
+	}
+	s.dialing = true
+	s.dial()
+	return s
+}
+
+// redial starts dialing in background if it is time to try again, the caller must hold the lock.
+func (s *connSink) redial() {
+	if s.dialing || s.closed || !s.backoff.ready(s.now()) {
+		return
+	}
+	s.dialing = true
+	go s.dial()
+}
+
+// dial connects to the remote end, the caller must set dialing.
+func (s *connSink) dial() {
+	conn, err := net.DialTimeout(s.network, s.addr, s.timeout)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dialing = false
+	switch {
+	case s.closed:
+		if conn != nil {
+			_ = conn.Close()
+		}
+	case err != nil:
+		s.backoff.fail(s.now())
+	default:
+		s.conn = conn
+		s.backoff.reset()
+	}
+}
+
+// newConnSink creates the sink of "tcp://host:port" or "udp://host:port", the entries are sent as they are encoded,
+// such as JSON lines.
+func newConnSink(u *url.URL) (zap.Sink, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("host is required: got %v", u.Redacted())
+	}
+	u = netURL(u)
+	opts, _, err := parseNetSinkOptions(u)
+	if err != nil {
+		return nil, err
+	}
+	return newConnSinkByOptions(u.Scheme, u.Host, opts), nil
+}
+
+func (s *connSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		s.redial()
+		return s.fallback.Write(p)
+	}
+	data := p
+	if s.frame != nil {
+		data = s.frame(p)
+	}
+	if s.timeout > 0 {
+		_ = s.conn.SetWriteDeadline(s.now().Add(s.timeout))
+	}
+	if _, err := s.conn.Write(data); err != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+		s.backoff.fail(s.now())
+		return s.fallback.Write(p)
+	}
+	return len(p), nil
+}
+
+func (s *connSink) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fallback.Sync()
+}
+
+func (s *connSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	var err error
+	if s.conn != nil {
+		err = s.conn.Close()
+		s.conn = nil
+	}
+	return errors.Join(err, s.fallback.Close())
+}
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+var syslogSeverities = map[string]int{
+	"fatal": 2, "panic": 2, "dpanic": 2, "error": 3, "warn": 4, "info": 6, "debug": 7,
+}
+
+// syslogFormatter formats the entries as RFC 5424 messages.
+type syslogFormatter struct {
+	facility int
+	severity int
+	hostname string
+	appName  string
+	procID   string
+	// octetCounting prefixes the length of the message for TCP, see RFC 6587.
+	octetCounting bool
+	now           func() time.Time
+}
+
+// newSyslogSink creates the sink of "syslog://host:port", the entries are sent as RFC 5424 messages. The query
+// parameters besides the common ones are:
+//
+//   - network: "udp" (default) or "tcp", TCP uses the octet counting framing.
+//   - facility: the facility name such as "user" (default) and "local0".
+//   - appName: the APP-NAME of the messages, default is the name of the executable.
+//   - severity: the severity of the entries without level, default is "info". The level of the JSON entries
+//     is used if it exists.
+func newSyslogSink(u *url.URL) (zap.Sink, error) {
+	u = netURL(u)
+	if u.Host == "" {
+		return nil, fmt.Errorf("host is required: got %v", u.Redacted())
+	}
+	opts, query, err := parseNetSinkOptions(u)
+	if err != nil {
+		return nil, err
+	}
+	network := query.Get("network")
+	switch network {
+	case "":
+		network = "udp"
+	case "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q", network)
+	}
+	f := &syslogFormatter{
+		facility:      syslogFacilities["user"],
+		severity:      syslogSeverities["info"],
+		appName:       query.Get("appName"),
+		procID:        strconv.Itoa(os.Getpid()),
+		octetCounting: network == "tcp",
+		now:           time.Now,
+	}
+	if v := query.Get("facility"); v != "" {
+		facility, ok := syslogFacilities[v]
+		if !ok {
+			return nil, fmt.Errorf("unknown syslog facility %q", v)
+		}
+		f.facility = facility
+	}
+	if v := query.Get("severity"); v != "" {
+		severity, ok := syslogSeverities[v]
+		if !ok {
+			return nil, fmt.Errorf("unknown syslog severity %q", v)
+		}
+		f.severity = severity
+	}
+	if f.appName == "" {
+		f.appName = filepath.Base(os.Args[0])
+	}
+	if f.hostname, err = os.Hostname(); err != nil || f.hostname == "" {
+		f.hostname = "-"
+	}
+	s := newConnSinkByOptions(network, u.Host, opts)
+	s.frame = f.format
+	return s, nil
+}
+
+// format returns the RFC 5424 message of the entry.
+func (f *syslogFormatter) format(p []byte) []byte {
+	msg := bytes.TrimRight(p, "\n")
+	severity := f.severity
+	var entry struct {
+		Level string `json:"level"`
+	}
+	if len(msg) > 0 && msg[0] == '{' && json.Unmarshal(msg, &entry) == nil {
+		if s, ok := syslogSeverities[strings.ToLower(entry.Level)]; ok {
+			severity = s
+		}
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %s - - ", f.facility*8+severity,
+		f.now().Format(time.RFC3339Nano), f.hostname, f.appName, f.procID)
+	buf.Write(msg)
+	if !f.octetCounting {
+		return buf.Bytes()
+	}
+	return append([]byte(strconv.Itoa(buf.Len())+" "), buf.Bytes()...)
+}
+
+// httpSink pushes the entries to an HTTP endpoint in batches in the Loki push format:
+//
+//	{"streams":[{"stream":{"job":"woocoo"},"values":[["<unix nano>","<entry>"]]}]}
+type httpSink struct {
+	endpoint  string
+	labels    map[string]string
+	tenant    string
+	batchSize int
+	client    *http.Client
+
+	mu       sync.Mutex
+	values   [][2]string
+	backoff  backoff
+	fallback fallbackFile
+	now      func() time.Time
+
+	pushMu sync.Mutex
+	wakeCh chan struct{}
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// newHTTPSink creates the sink of "http(s)://host/path", such as "http://loki:3100/loki/api/v1/push". The query
+// parameters besides the common ones are:
+//
+//   - batchSize: the max number of entries of a push, default 100.
+//   - flushInterval: the max interval between two pushes, default 1s.
+//   - labels: the labels of the stream such as "app=foo,env=prod", default is "job=woocoo".
+//   - tenant: the value of the X-Scope-OrgID header.
+//
+// The other query parameters are kept in the endpoint.
+func newHTTPSink(u *url.URL) (zap.Sink, error) {
+	u = netURL(u)
+	opts, query, err := parseNetSinkOptions(u)
+	if err != nil {
+		return nil, err
+	}
+	s := &httpSink{
+		labels:    map[string]string{"job": "woocoo"},
+		tenant:    query.Get("tenant"),
+		batchSize: defaultHTTPBatchSize,
+		client:    &http.Client{Timeout: opts.timeout},
+		backoff:   backoff{min: opts.backoff, max: opts.maxBackoff},
+		fallback:  fallbackFile{path: opts.fallback},
+		now:       time.Now,
+		wakeCh:    make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+	interval := defaultHTTPFlushInterval
+	if v := query.Get("flushInterval"); v != "" {
+		if interval, err = time.ParseDuration(v); err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid flushInterval of %s", u.Redacted())
+		}
+	}
+	if v := query.Get("batchSize"); v != "" {
+		if s.batchSize, err = strconv.Atoi(v); err != nil || s.batchSize <= 0 {
+			return nil, fmt.Errorf("invalid batchSize of %s", u.Redacted())
+		}
+	}
+	if v := query.Get("labels"); v != "" {
+		s.labels = map[string]string{}
+		for _, kv := range strings.Split(v, ",") {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok || k == "" {
+				return nil, fmt.Errorf("invalid labels of %s", u.Redacted())
+			}
+			s.labels[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	for _, key := range []string{"batchSize", "flushInterval", "labels", "tenant"} {
+		query.Del(key)
+	}
+	eu := *u
+	eu.RawQuery = query.Encode()
+	s.endpoint = eu.String()
+	go s.run(interval)
+	return s, nil
+}
+
+func (s *httpSink) Write(p []byte) (int, error) {
+	line := string(bytes.TrimRight(p, "\n"))
+	s.mu.Lock()
+	s.values = append(s.values, [2]string{strconv.FormatInt(s.now().UnixNano(), 10), line})
+	full := len(s.values) >= s.batchSize
+	s.mu.Unlock()
+	if full {
+		select {
+		case s.wakeCh <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+func (s *httpSink) run(interval time.Duration) {
+	defer close(s.doneCh)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		case <-s.wakeCh:
+		}
+		_ = s.flush()
+	}
+}
+
+// flush pushes the buffered entries in batches, the entries are written to the fallback file if the push fails or
+// it is in backoff.
+func (s *httpSink) flush() error {
+	s.pushMu.Lock()
+	defer s.pushMu.Unlock()
+	s.mu.Lock()
+	values := s.values
+	s.values = nil
+	s.mu.Unlock()
+	var errs error
+	for len(values) > 0 {
+		n := min(len(values), s.batchSize)
+		batch := values[:n]
+		values = values[n:]
+		now := s.now()
+		if s.backoff.ready(now) {
+			err := s.push(batch)
+			if err == nil {
+				s.backoff.reset()
+				continue
+			}
+			s.backoff.fail(now)
+			errs = errors.Join(errs, err)
+		}
+		for _, v := range batch {
+			if _, err := s.fallback.Write([]byte(v[1] + "\n")); err != nil {
+				errs = errors.Join(errs, err)
+				break
+			}
+		}
+	}
+	return errs
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (s *httpSink) push(values [][2]string) error {
+	body, err := json.Marshal(map[string][]lokiStream{
+		"streams": {{Stream: s.labels, Values: values}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.tenant != "" {
+		req.Header.Set("X-Scope-OrgID", s.tenant)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("push logs to %s: %s", s.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// Sync pushes the buffered entries.
+func (s *httpSink) Sync() error {
+	err := s.flush()
+	s.pushMu.Lock()
+	defer s.pushMu.Unlock()
+	return errors.Join(err, s.fallback.Sync())
+}
+
+func (s *httpSink) Close() error {
+	close(s.stopCh)
+	<-s.doneCh
+	err := s.flush()
+	s.pushMu.Lock()
+	defer s.pushMu.Unlock()
+	return errors.Join(err, s.fallback.Close())
+}
@@ -0,0 +1,355 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"go.uber.org/zap"
+)
+
+// tcpLines accepts the connections of the listener and sends the received lines to the channel.
+func tcpLines(t *testing.T, ln net.Listener) <-chan string {
+	ch := make(chan string, 100)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sc := bufio.NewScanner(conn)
+				for sc.Scan() {
+					ch <- sc.Text()
+				}
+			}()
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return ch
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(3 * time.Second):
+		t.Fatal("receive timeout")
+		return ""
+	}
+}
+
+func TestConnSink(t *testing.T) {
+	t.Run("tcp", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		lines := tcpLines(t, ln)
+		sink, err := newConnSink(&url.URL{Scheme: "tcp", Host: ln.Addr().String()})
+		require.NoError(t, err)
+		defer sink.Close()
+		_, err = sink.Write([]byte(`{"msg":"hello"}` + "\n"))
+		require.NoError(t, err)
+		assert.Equal(t, `{"msg":"hello"}`, receive(t, lines))
+	})
+	t.Run("udp", func(t *testing.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer pc.Close()
+		sink, err := newConnSink(&url.URL{Scheme: "udp", Host: pc.LocalAddr().String()})
+		require.NoError(t, err)
+		defer sink.Close()
+		_, err = sink.Write([]byte(`{"msg":"hello"}` + "\n"))
+		require.NoError(t, err)
+		buf := make([]byte, 1024)
+		require.NoError(t, pc.SetReadDeadline(time.Now().Add(3*time.Second)))
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.Equal(t, `{"msg":"hello"}`+"\n", string(buf[:n]))
+	})
+	t.Run("fallback and reconnect", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+		fallback := filepath.Join(t.TempDir(), "fallback.log")
+		u, err := url.Parse("tcp://" + addr + "?backoff=1h&fallback=" + url.QueryEscape(fallback))
+		require.NoError(t, err)
+		sink, err := newConnSink(u)
+		require.NoError(t, err)
+		defer sink.Close()
+		cs := sink.(*connSink)
+		now := time.Now()
+		cs.now = func() time.Time { return now }
+
+		_, err = sink.Write([]byte("down-1\n"))
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, cs.backoff.cur)
+
+		ln, err = net.Listen("tcp", addr)
+		require.NoError(t, err)
+		lines := tcpLines(t, ln)
+		_, err = sink.Write([]byte("down-2\n"))
+		require.NoError(t, err, "should not redial in backoff")
+		require.NoError(t, sink.Sync())
+		bs, err := os.ReadFile(fallback)
+		require.NoError(t, err)
+		assert.Equal(t, "down-1\ndown-2\n", string(bs))
+
+		cs.mu.Lock()
+		now = now.Add(time.Hour)
+		cs.mu.Unlock()
+		_, err = sink.Write([]byte("redialing\n"))
+		require.NoError(t, err, "should not wait for the dialing")
+		assert.Eventually(t, func() bool {
+			cs.mu.Lock()
+			defer cs.mu.Unlock()
+			return cs.conn != nil
+		}, 3*time.Second, 10*time.Millisecond)
+		_, err = sink.Write([]byte("up\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "up", receive(t, lines))
+		cs.mu.Lock()
+		assert.Zero(t, cs.backoff.cur, "backoff should be reset")
+		cs.mu.Unlock()
+	})
+	t.Run("no fallback", func(t *testing.T) {
+		sink, err := newConnSink(&url.URL{Scheme: "tcp", Host: "127.0.0.1:1", RawQuery: "timeout=100ms"})
+		require.NoError(t, err)
+		_, err = sink.Write([]byte("lost\n"))
+		assert.Error(t, err)
+	})
+	t.Run("fail fast while dialing", func(t *testing.T) {
+		fallback := filepath.Join(t.TempDir(), "fallback.log")
+		cs := newConnSinkByOptions("tcp", "127.0.0.1:1", netSinkOptions{timeout: 2 * time.Second, fallback: fallback})
+		defer cs.Close()
+		cs.mu.Lock()
+		// the non-routable address makes the dialing hang until the timeout.
+		cs.addr = "10.255.255.1:9"
+		cs.backoff.reset()
+		cs.mu.Unlock()
+		begin := time.Now()
+		for i := 0; i < 10; i++ {
+			_, err := cs.Write([]byte("down\n"))
+			require.NoError(t, err)
+		}
+		assert.Less(t, time.Since(begin), time.Second)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	b := backoff{min: time.Second, max: 3 * time.Second}
+	now := time.Now()
+	assert.True(t, b.ready(now))
+	b.fail(now)
+	assert.False(t, b.ready(now))
+	assert.True(t, b.ready(now.Add(time.Second)))
+	b.fail(now)
+	assert.Equal(t, 2*time.Second, b.cur)
+	b.fail(now)
+	assert.Equal(t, 3*time.Second, b.cur)
+	b.reset()
+	assert.True(t, b.ready(now))
+}
+
+func TestSyslogSink(t *testing.T) {
+	t.Run("udp", func(t *testing.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer pc.Close()
+		u, err := url.Parse("syslog://" + pc.LocalAddr().String() + "?facility=local0&appName=woocoo")
+		require.NoError(t, err)
+		sink, err := newSyslogSink(u)
+		require.NoError(t, err)
+		defer sink.Close()
+		_, err = sink.Write([]byte(`{"level":"error","msg":"hello"}` + "\n"))
+		require.NoError(t, err)
+		buf := make([]byte, 1024)
+		require.NoError(t, pc.SetReadDeadline(time.Now().Add(3*time.Second)))
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		// local0(16)*8 + error(3)
+		msg := string(buf[:n])
+		assert.True(t, strings.HasPrefix(msg, "<131>1 "), msg)
+		assert.Contains(t, msg, " woocoo ")
+		assert.True(t, strings.HasSuffix(msg, ` - - {"level":"error","msg":"hello"}`), msg)
+	})
+	t.Run("tcp", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+		u, err := url.Parse("syslog://" + ln.Addr().String() + "?network=tcp&severity=warn")
+		require.NoError(t, err)
+		sink, err := newSyslogSink(u)
+		require.NoError(t, err)
+		defer sink.Close()
+		_, err = sink.Write([]byte("plain text\n"))
+		require.NoError(t, err)
+		conn, err := ln.Accept()
+		require.NoError(t, err)
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		size, err := r.ReadString(' ')
+		require.NoError(t, err)
+		n, err := strconv.Atoi(strings.TrimSpace(size))
+		require.NoError(t, err)
+		msg := make([]byte, n)
+		_, err = io.ReadFull(r, msg)
+		require.NoError(t, err)
+		// user(1)*8 + warn(4)
+		assert.True(t, strings.HasPrefix(string(msg), "<12>1 "), string(msg))
+		assert.True(t, strings.HasSuffix(string(msg), " - - plain text"), string(msg))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, raw := range []string{"syslog://127.0.0.1:514?facility=none", "syslog://127.0.0.1:514?network=unix",
+			"syslog://127.0.0.1:514?severity=none", "syslog://"} {
+			u, err := url.Parse(raw)
+			require.NoError(t, err)
+			_, err = newSyslogSink(u)
+			assert.Error(t, err, raw)
+		}
+	})
+}
+
+// lokiServer records the pushed streams.
+type lokiServer struct {
+	mu      sync.Mutex
+	streams []lokiStream
+	tenant  string
+	status  int
+}
+
+func (l *lokiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.status != 0 {
+		w.WriteHeader(l.status)
+		return
+	}
+	var body struct {
+		Streams []lokiStream `json:"streams"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	l.tenant = r.Header.Get("X-Scope-OrgID")
+	l.streams = append(l.streams, body.Streams...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (l *lokiServer) lines() (lines []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.streams {
+		for _, v := range s.Values {
+			lines = append(lines, v[1])
+		}
+	}
+	return
+}
+
+func TestHTTPSink(t *testing.T) {
+	t.Run("batch", func(t *testing.T) {
+		loki := &lokiServer{}
+		srv := httptest.NewServer(loki)
+		defer srv.Close()
+		u, err := url.Parse(srv.URL + "/loki/api/v1/push?batchSize=2&flushInterval=1h&labels=app=foo,env=test&tenant=t1")
+		require.NoError(t, err)
+		sink, err := newHTTPSink(u)
+		require.NoError(t, err)
+		defer sink.Close()
+		assert.Equal(t, srv.URL+"/loki/api/v1/push", sink.(*httpSink).endpoint)
+
+		for _, s := range []string{"a\n", "b\n"} {
+			_, err = sink.Write([]byte(s))
+			require.NoError(t, err)
+		}
+		assert.Eventually(t, func() bool {
+			return len(loki.lines()) == 2
+		}, 3*time.Second, 10*time.Millisecond, "should push when the batch is full")
+		_, err = sink.Write([]byte("c\n"))
+		require.NoError(t, err)
+		require.NoError(t, sink.Sync())
+		assert.Equal(t, []string{"a", "b", "c"}, loki.lines())
+		loki.mu.Lock()
+		defer loki.mu.Unlock()
+		assert.Equal(t, map[string]string{"app": "foo", "env": "test"}, loki.streams[0].Stream)
+		assert.Equal(t, "t1", loki.tenant)
+	})
+	t.Run("fallback", func(t *testing.T) {
+		loki := &lokiServer{status: http.StatusServiceUnavailable}
+		srv := httptest.NewServer(loki)
+		defer srv.Close()
+		fallback := filepath.Join(t.TempDir(), "fallback.log")
+		u, err := url.Parse(srv.URL + "?flushInterval=1h&backoff=1h&fallback=" + url.QueryEscape(fallback))
+		require.NoError(t, err)
+		sink, err := newHTTPSink(u)
+		require.NoError(t, err)
+		defer sink.Close()
+		hs := sink.(*httpSink)
+		now := time.Now()
+		hs.now = func() time.Time { return now }
+
+		_, err = sink.Write([]byte("a\n"))
+		require.NoError(t, err)
+		assert.ErrorContains(t, sink.Sync(), "503")
+		loki.mu.Lock()
+		loki.status = 0
+		loki.mu.Unlock()
+		_, err = sink.Write([]byte("b\n"))
+		require.NoError(t, err)
+		require.NoError(t, sink.Sync(), "should write to fallback in backoff")
+		bs, err := os.ReadFile(fallback)
+		require.NoError(t, err)
+		assert.Equal(t, "a\nb\n", string(bs))
+
+		now = now.Add(time.Hour)
+		_, err = sink.Write([]byte("c\n"))
+		require.NoError(t, err)
+		require.NoError(t, sink.Sync())
+		assert.Equal(t, []string{"c"}, loki.lines())
+	})
+}
+
+func TestConfig_NetSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	lines := tcpLines(t, ln)
+	dir := t.TempDir()
+	cfg := conf.NewFromStringMap(map[string]any{
+		"cores": []any{
+			map[string]any{"level": "info", "outputPaths": []any{"tcp://" + ln.Addr().String() + "?fallback=fallback.log"}},
+		},
+	})
+	cfg.SetBaseDir(dir)
+	config, err := NewConfig(cfg)
+	require.NoError(t, err)
+	zl, err := config.BuildZap()
+	require.NoError(t, err)
+	zl.Info("net-message", zap.String("key", "value"))
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal([]byte(receive(t, lines)), &entry))
+	assert.Equal(t, "net-message", entry["msg"])
+	assert.Equal(t, "value", entry["key"])
+
+	got, err := convertPath("tcp://127.0.0.1:1?fallback=fallback.log", dir, true)
+	require.NoError(t, err)
+	assert.Equal(t, "woocoo-tcp://127.0.0.1:1?fallback="+url.QueryEscape(filepath.Join(dir, "fallback.log")), got)
+	_, _, err = zap.Open("tcp://127.0.0.1:1")
+	assert.Error(t, err, "the generic schemes should not be registered to zap")
+}
@@ -0,0 +1,277 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultRedactMask is the mask of the redacted values.
+const DefaultRedactMask = "******"
+
+var (
+	// DefaultRedactKeys are the default keys of RedactConfig.
+	DefaultRedactKeys = []string{"authorization", "cookie", "password", "secret", "token"}
+	// DefaultRedactPatterns are the default patterns of RedactConfig. The "card" and "phone" patterns are not
+	// included, because they match the numeric ids such as order numbers, add them explicitly if needed.
+	DefaultRedactPatterns = []string{"bearer"}
+
+	// builtinRedactPatterns are the patterns can be used by name in RedactConfig.Patterns.
+	builtinRedactPatterns = map[string]string{
+		// card numbers of 13-19 digits, separated by space or dash optionally, checked by Luhn algorithm.
+		"card": `\b\d(?:[ -]?\d){11,17}[ -]?\d\b`,
+		// phone numbers such as +86 138 0013 8000, 13800138000, (555) 123-4567.
+		"phone": `(?:\+\d{1,3}[ -]?)?(?:\(\d{3}\)|\b\d{3})[ -]?\d{3,4}[ -]?\d{4}\b`,
+		// bearer tokens in Authorization values.
+		"bearer": `(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`,
+	}
+
+	defaultRedactor     *Redactor
+	defaultRedactorOnce sync.Once
+)
+
+// RedactConfig is the settings of redaction, the sensitive values are masked before encoding. Configuration example:
+//
+//	redact:
+//	  keys: [authorization, password]
+//	  patterns: [card, phone, bearer, 'id_card=\d+']
+//	  mask: "***"
+type RedactConfig struct {
+	// Keys are the case-insensitive substrings of the field keys whose values are masked, such as "authorization"
+	// matches the field "header:Authorization". The "key=value" and `"key":"value"` pairs in string values are
+	// masked too, such as the password in a request body. Default is DefaultRedactKeys.
+	Keys []string `json:"keys" yaml:"keys"`
+	// Patterns are the regular expressions matching the sensitive parts of string values, the matched parts are
+	// masked. The built-in names can be used: "card" for card numbers, "phone" for phone numbers and "bearer" for
+	// bearer tokens. Default is DefaultRedactPatterns.
+	Patterns []string `json:"patterns" yaml:"patterns"`
+	// Mask is the replacement of the sensitive values, default is DefaultRedactMask.
+	Mask string `json:"mask" yaml:"mask"`
+}
+
+type redactPattern struct {
+	re *regexp.Regexp
+	// valid checks the matched value, nil means always valid.
+	valid func(string) bool
+}
+
+// Redactor masks the sensitive values of the log fields.
+type Redactor struct {
+	keys     []string
+	kvRegexp *regexp.Regexp
+	patterns []redactPattern
+	mask     string
+}
+
+// NewRedactor creates a Redactor by the configuration, it returns error if any pattern is invalid.
+func NewRedactor(cfg RedactConfig) (*Redactor, error) {
+	if cfg.Keys == nil {
+		cfg.Keys = DefaultRedactKeys
+	}
+	if cfg.Patterns == nil {
+		cfg.Patterns = DefaultRedactPatterns
+	}
+	r := &Redactor{mask: cfg.Mask}
+	if r.mask == "" {
+		r.mask = DefaultRedactMask
+	}
+	quoted := make([]string, 0, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		if k == "" {
+			continue
+		}
+		r.keys = append(r.keys, strings.ToLower(k))
+		quoted = append(quoted, regexp.QuoteMeta(k))
+	}
+	if len(quoted) > 0 {
+		r.kvRegexp = regexp.MustCompile(`(?i)("?[\w.-]*(?:` + strings.Join(quoted, "|") +
+			`)[\w.-]*"?\s*[:=]\s*"?)([^"&,;\s}]+)`)
+	}
+	for _, p := range cfg.Patterns {
+		rp := redactPattern{}
+		expr, ok := builtinRedactPatterns[p]
+		if !ok {
+			expr = p
+		}
+		if p == "card" {
+			rp.valid = luhnValid
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		rp.re = re
+		r.patterns = append(r.patterns, rp)
+	}
+	return r, nil
+}
+
+// DefaultRedactor returns the Redactor with the default keys and patterns, it is used by the access logs if the
+// logger has no redaction configuration, see Logger.RedactFields.
+func DefaultRedactor() *Redactor {
+	defaultRedactorOnce.Do(func() {
+		defaultRedactor, _ = NewRedactor(RedactConfig{})
+	})
+	return defaultRedactor
+}
+
+// MatchKey reports whether the values of the field key should be masked.
+func (r *Redactor) MatchKey(key string) bool {
+	lk := strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(lk, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// String masks the sensitive parts of s.
+func (r *Redactor) String(s string) string {
+	if s == "" {
+		return s
+	}
+	for _, p := range r.patterns {
+		if p.valid == nil {
+			s = p.re.ReplaceAllString(s, r.mask)
+			continue
+		}
+		s = p.re.ReplaceAllStringFunc(s, func(m string) string {
+			if p.valid(m) {
+				return r.mask
+			}
+			return m
+		})
+	}
+	if r.kvRegexp != nil {
+		s = r.kvRegexp.ReplaceAllString(s, "${1}"+r.mask)
+	}
+	return s
+}
+
+// Field returns the field with the sensitive value masked. The string, byte string, stringer, error and reflected
+// values are checked, the reflected values are checked in JSON. The object and array marshalers are only checked
+// by the key.
+func (r *Redactor) Field(f zap.Field) zap.Field {
+	rf, _ := r.field(f)
+	return rf
+}
+
+// field returns the masked field and whether it is changed.
+func (r *Redactor) field(f zap.Field) (zap.Field, bool) {
+	switch f.Type {
+	case zapcore.SkipType, zapcore.NamespaceType:
+		return f, false
+	}
+	if r.MatchKey(f.Key) {
+		return zap.String(f.Key, r.mask), true
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		if s := r.String(f.String); s != f.String {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ByteStringType:
+		bs, _ := f.Interface.([]byte)
+		if s := r.String(string(bs)); s != string(bs) {
+			return zap.ByteString(f.Key, []byte(s)), true
+		}
+	case zapcore.StringerType, zapcore.ErrorType:
+		var v string
+		switch i := f.Interface.(type) {
+		case fmt.Stringer:
+			v = i.String()
+		case error:
+			v = i.Error()
+		default:
+			return f, false
+		}
+		if s := r.String(v); s != v {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ReflectType:
+		bs, err := json.Marshal(f.Interface)
+		if err != nil {
+			return f, false
+		}
+		if s := r.String(string(bs)); s != string(bs) {
+			return zap.Any(f.Key, json.RawMessage(s)), true
+		}
+	}
+	return f, false
+}
+
+// Fields returns the fields with the sensitive values masked, the slice is copied only if any field changed.
+func (r *Redactor) Fields(fields []zap.Field) []zap.Field {
+	var res []zap.Field
+	for i, f := range fields {
+		rf, changed := r.field(f)
+		if res == nil {
+			if !changed {
+				continue
+			}
+			res = make([]zap.Field, len(fields))
+			copy(res, fields[:i])
+		}
+		res[i] = rf
+	}
+	if res == nil {
+		return fields
+	}
+	return res
+}
+
+// luhnValid checks the digits of s by Luhn algorithm.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 0 && sum%10 == 0
+}
+
+// redactCore masks the fields before writing to the inner core.
+type redactCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+func newRedactCore(core zapcore.Core, r *Redactor) zapcore.Core {
+	if r == nil {
+		return core
+	}
+	return &redactCore{Core: core, redactor: r}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactor.Fields(fields)), redactor: c.redactor}
+}
+
+// Check adds itself to write the entry, the sampling of the cores built by Config is applied on top of it.
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.redactor.Fields(fields))
+}
@@ -0,0 +1,119 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"go.uber.org/zap"
+)
+
+func TestRedactor(t *testing.T) {
+	r, err := NewRedactor(RedactConfig{Patterns: []string{"card", "phone", "bearer", `id=\d+`}})
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		field zap.Field
+		want  zap.Field
+	}{
+		{name: "key", field: zap.String("header:Authorization", "Basic xyz"), want: zap.String("header:Authorization", "******")},
+		{name: "key not string", field: zap.Int("tokenExpiry", 10), want: zap.String("tokenExpiry", "******")},
+		{name: "bearer", field: zap.String("msg", "auth Bearer abc.def-g"), want: zap.String("msg", "auth ******")},
+		{name: "card", field: zap.String("msg", "pay by 4111 1111 1111 1111."), want: zap.String("msg", "pay by ******.")},
+		{name: "not card", field: zap.String("msg", "at 1700000000001"), want: zap.String("msg", "at 1700000000001")},
+		{name: "phone", field: zap.String("msg", "call +86 138 0013 8000 or (555) 123-4567"), want: zap.String("msg", "call ****** or ******")},
+		{name: "custom", field: zap.ByteString("msg", []byte("id=123")), want: zap.ByteString("msg", []byte("******"))},
+		{name: "json body", field: zap.String("bodyIn", `{"username":"u","password": "p1"}`), want: zap.String("bodyIn", `{"username":"u","password": "******"}`)},
+		{name: "form body", field: zap.String("uri", "/login?user=u&access_token=t1"), want: zap.String("uri", "/login?user=u&access_token=******")},
+		{name: "error", field: zap.Error(errors.New("password=p1")), want: zap.String("error", "password=******")},
+		{name: "reflect", field: zap.Any("req", map[string]any{"Secret": "s", "name": "n"})},
+		{name: "no change", field: zap.String("name", "n"), want: zap.String("name", "n")},
+		{name: "skip", field: zap.Skip(), want: zap.Skip()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.Field(tt.field)
+			if tt.name == "reflect" {
+				assert.JSONEq(t, `{"Secret":"******","name":"n"}`, string(got.Interface.(json.RawMessage)))
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	fields := []zap.Field{zap.String("name", "n"), zap.String("password", "p")}
+	got := r.Fields(fields)
+	assert.Equal(t, "p", fields[1].String, "should not change the input")
+	assert.Equal(t, []zap.Field{zap.String("name", "n"), zap.String("password", "******")}, got)
+	assert.Equal(t, fields[:1], r.Fields(fields[:1]))
+
+	_, err = NewRedactor(RedactConfig{Patterns: []string{"("}})
+	assert.Error(t, err)
+}
+
+func TestConfig_Redact(t *testing.T) {
+	for _, encoding := range []string{"json", "text"} {
+		t.Run(encoding, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "redact.log")
+			cfg := conf.NewFromStringMap(map[string]any{
+				"disableSampling": true,
+				"cores": []any{
+					map[string]any{"level": "info", "encoding": encoding, "outputPaths": []any{file}},
+				},
+				"redact": map[string]any{"keys": []any{"password"}, "patterns": []any{"card"}, "mask": "[redacted]"},
+			})
+			logger := NewFromConf(cfg)
+			logger.With(zap.String("password", "p1")).Info("login",
+				zap.String("card", "4111111111111111"), zap.String("token", "t1"))
+			bs, err := os.ReadFile(file)
+			require.NoError(t, err)
+			got := string(bs)
+			assert.NotContains(t, got, "p1")
+			assert.NotContains(t, got, "4111111111111111")
+			assert.Contains(t, got, "t1", "token is not in the configured keys")
+			assert.Contains(t, got, "[redacted]")
+			assert.NotSame(t, DefaultRedactor(), logger.Redactor())
+		})
+	}
+	t.Run("sampling", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "redact.log")
+		logger := NewFromConf(conf.NewFromStringMap(map[string]any{
+			"cores": []any{map[string]any{
+				"level": "info", "outputPaths": []any{file},
+				"sampling": map[string]any{"initial": 1, "thereafter": 100},
+			}},
+			"redact": map[string]any{"keys": []any{"password"}},
+		}))
+		for i := 0; i < 3; i++ {
+			logger.Info("login", zap.String("password", "p1"))
+		}
+		bs, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(string(bs), "login"), "should keep the sampling")
+		assert.NotContains(t, string(bs), "p1")
+	})
+	_, err := NewConfig(conf.NewFromStringMap(map[string]any{
+		"cores":  []any{map[string]any{"level": "info"}},
+		"redact": map[string]any{"patterns": []any{"("}},
+	}))
+	assert.Error(t, err)
+	assert.Same(t, DefaultRedactor(), New(zap.NewNop()).Redactor())
+}
+
+func TestLogger_RedactFields(t *testing.T) {
+	fields := []zap.Field{zap.String("orderId", "4111111111111111"), zap.String("password", "p1")}
+	got := New(zap.NewNop()).RedactFields(fields)
+	assert.Equal(t, []zap.Field{zap.String("orderId", "4111111111111111"), zap.String("password", "******")}, got,
+		"the default redactor should not mask the numeric ids")
+
+	logger := NewFromConf(conf.NewFromStringMap(map[string]any{
+		"cores":  []any{map[string]any{"level": "info"}},
+		"redact": map[string]any{"keys": []any{"password"}},
+	}))
+	assert.Equal(t, fields, logger.RedactFields(fields), "the configured cores mask the fields")
+}
@@ -0,0 +1,376 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// RotateHourly rotates the log files at the beginning of every hour.
+	RotateHourly = "hourly"
+	// RotateDaily rotates the log files at the beginning of every day.
+	RotateDaily = "daily"
+
+	compressSuffix = ".gz"
+)
+
+var (
+	timeRotateMu      sync.Mutex
+	timeRotateWriters = map[string]*timeRotateWriter{}
+	// timeVerbRegexp matches the time verbs and their leading separator in a file name pattern.
+	timeVerbRegexp = regexp.MustCompile(`[-_.]?(?:%[YmdH])+`)
+	// timeVerbSplitRegexp matches a single time verb.
+	timeVerbSplitRegexp = regexp.MustCompile(`%[YmdH]`)
+)
+
+// timeRotateWriter writes to the file named by the pattern and the current time, and switches to the new file when
+// the period changes. The rotated files are compressed and removed by the retention settings in background.
+//
+// The writers are shared by the pattern, so that the cores writing to the same file use one writer.
+type timeRotateWriter struct {
+	pattern    string
+	dir        string
+	hourly     bool
+	localTime  bool
+	compress   bool
+	maxAge     time.Duration
+	maxBackups int
+	maxTotal   int64
+	link       string
+	matcher    *regexp.Regexp
+	now        func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	filename string
+	next     time.Time
+	refs     int
+
+	// milling reports whether the background goroutine is running.
+	milling  bool
+	millCh   chan struct{}
+	stopCh   chan struct{}
+	millDone chan struct{}
+}
+
+// hasTimeVerb reports whether the path contains the time verbs of the time rotation.
+func hasTimeVerb(path string) bool {
+	return timeVerbRegexp.MatchString(path)
+}
+
+// defaultRotatePattern returns the pattern of the path without time verbs, the time verbs of the interval are
+// inserted before the extension, such as "logs/app.log" to "logs/app-%Y%m%d.log".
+func defaultRotatePattern(path, interval string) string {
+	ext := filepath.Ext(path)
+	verbs := "-%Y%m%d"
+	if interval == RotateHourly {
+		verbs += "%H"
+	}
+	return strings.TrimSuffix(path, ext) + verbs + ext
+}
+
+// openTimeRotateWriter returns the shared writer of the path, the path is used as the pattern if it contains time
+// verbs, otherwise the default pattern is used and the path is the symlink of the current file if enabled.
+func openTimeRotateWriter(path string, r *rotate) (*timeRotateWriter, error) {
+	pattern, link := path, ""
+	if hasTimeVerb(path) {
+		link = filepath.Join(filepath.Dir(path), timeVerbRegexp.ReplaceAllString(filepath.Base(path), ""))
+	} else {
+		pattern, link = defaultRotatePattern(path, r.Interval), path
+	}
+	if !r.Symlink {
+		link = ""
+	}
+	timeRotateMu.Lock()
+	defer timeRotateMu.Unlock()
+	if w, ok := timeRotateWriters[pattern]; ok {
+		w.refs++
+		return w, nil
+	}
+	w, err := newTimeRotateWriter(pattern, link, r)
+	if err != nil {
+		return nil, err
+	}
+	w.refs, w.milling = 1, true
+	timeRotateWriters[pattern] = w
+	go w.run()
+	return w, nil
+}
+
+func newTimeRotateWriter(pattern, link string, r *rotate) (*timeRotateWriter, error) {
+	if strings.Contains(filepath.Dir(pattern), "%") {
+		return nil, fmt.Errorf("time verbs are only allowed in the file name: %s", pattern)
+	}
+	if strings.Contains(timeVerbRegexp.ReplaceAllString(filepath.Base(pattern), ""), "%") {
+		return nil, fmt.Errorf("unknown time verb in %s, supported verbs are %%Y %%m %%d %%H", pattern)
+	}
+	expr := ""
+	for _, part := range timeVerbSplitRegexp.Split(filepath.Base(pattern), -1) {
+		if expr != "" {
+			expr += `\d+`
+		}
+		expr += regexp.QuoteMeta(part)
+	}
+	w := &timeRotateWriter{
+		pattern:    pattern,
+		dir:        filepath.Dir(pattern),
+		hourly:     r.Interval == RotateHourly,
+		localTime:  r.LocalTime,
+		compress:   r.Compress,
+		maxAge:     time.Duration(r.MaxAge) * 24 * time.Hour,
+		maxBackups: r.MaxBackups,
+		maxTotal:   int64(r.MaxTotalSize) * 1024 * 1024,
+		link:       link,
+		matcher:    regexp.MustCompile("^" + expr + "(" + regexp.QuoteMeta(compressSuffix) + ")?$"),
+		now:        time.Now,
+		millCh:     make(chan struct{}, 1),
+		stopCh:     make(chan struct{}),
+		millDone:   make(chan struct{}),
+	}
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// formatPattern replaces the time verbs of the pattern by t.
+func formatPattern(pattern string, t time.Time) string {
+	return strings.NewReplacer(
+		"%Y", fmt.Sprintf("%04d", t.Year()),
+		"%m", fmt.Sprintf("%02d", t.Month()),
+		"%d", fmt.Sprintf("%02d", t.Day()),
+		"%H", fmt.Sprintf("%02d", t.Hour()),
+	).Replace(pattern)
+}
+
+func (w *timeRotateWriter) currentTime() time.Time {
+	if w.localTime {
+		return w.now()
+	}
+	return w.now().UTC()
+}
+
+// Write writes p to the file of the current period.
+func (w *timeRotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if t := w.currentTime(); w.file == nil || !t.Before(w.next) {
+		if err := w.rotate(t); err != nil {
+			return 0, err
+		}
+	}
+	return w.file.Write(p)
+}
+
+// rotate opens the file of the period of t and updates the symlink, the caller must hold the lock.
+func (w *timeRotateWriter) rotate(t time.Time) error {
+	y, m, d := t.Date()
+	h := 0
+	if w.hourly {
+		h = t.Hour()
+	}
+	start := time.Date(y, m, d, h, 0, 0, 0, t.Location())
+	name := formatPattern(w.pattern, start)
+	if w.file == nil || name != w.filename {
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		if w.file != nil {
+			_ = w.file.Close()
+		}
+		w.file, w.filename = f, name
+		if w.link != "" {
+			// the failure of the symlink such as unsupported by the file system does not stop the logging.
+			_ = replaceSymlink(filepath.Base(name), w.link)
+		}
+		w.mill()
+	}
+	if w.hourly {
+		w.next = start.Add(time.Hour)
+	} else {
+		w.next = start.AddDate(0, 0, 1)
+	}
+	return nil
+}
+
+// replaceSymlink points the link to target atomically.
+func replaceSymlink(target, link string) error {
+	tmp := link + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, link)
+}
+
+// Sync implements zap.Sink.
+func (w *timeRotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close implements zap.Sink, the writer is closed when all the cores sharing it are closed.
+func (w *timeRotateWriter) Close() error {
+	timeRotateMu.Lock()
+	w.refs--
+	if w.refs > 0 {
+		timeRotateMu.Unlock()
+		return nil
+	}
+	delete(timeRotateWriters, w.pattern)
+	timeRotateMu.Unlock()
+
+	if w.milling {
+		close(w.stopCh)
+		<-w.millDone
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// mill notifies the background goroutine to compress and remove the rotated files.
+func (w *timeRotateWriter) mill() {
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+}
+
+func (w *timeRotateWriter) run() {
+	defer close(w.millDone)
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-w.millCh:
+			_ = w.millRunOnce()
+		}
+	}
+}
+
+type rotatedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// millRunOnce compresses the rotated files, and removes them by MaxAge, MaxBackups and MaxTotalSize from the oldest.
+// The current file is counted in the total size but never removed.
+func (w *timeRotateWriter) millRunOnce() error {
+	w.mu.Lock()
+	current := w.filename
+	w.mu.Unlock()
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return err
+	}
+	var (
+		files []rotatedFile
+		total int64
+		errs  []error
+	)
+	for _, e := range entries {
+		path := filepath.Join(w.dir, e.Name())
+		if !e.Type().IsRegular() || !w.matcher.MatchString(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if path == current {
+			total += info.Size()
+			continue
+		}
+		if w.compress && !strings.HasSuffix(path, compressSuffix) {
+			if err := compressLogFile(path); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			path += compressSuffix
+			if info, err = os.Stat(path); err != nil {
+				continue
+			}
+		}
+		files = append(files, rotatedFile{path: path, size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	cutoff := w.now().Add(-w.maxAge)
+	kept := 0
+	for _, f := range files {
+		if (w.maxAge > 0 && f.modTime.Before(cutoff)) ||
+			(w.maxBackups > 0 && kept >= w.maxBackups) ||
+			(w.maxTotal > 0 && total+f.size > w.maxTotal) {
+			if err := os.Remove(f.path); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		kept++
+		total += f.size
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("mill rotated files of %s: %v", w.pattern, errs)
+	}
+	return nil
+}
+
+// compressLogFile compresses the file to a gzip file with the same modification time and removes the file.
+func compressLogFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + compressSuffix)
+		}
+	}()
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	if err = os.Chtimes(path+compressSuffix, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,198 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func TestTimeRotateWriter(t *testing.T) {
+	t.Run("daily", func(t *testing.T) {
+		dir := t.TempDir()
+		now := time.Date(2026, 10, 16, 23, 59, 0, 0, time.UTC)
+		w, err := newTimeRotateWriter(filepath.Join(dir, "app-%Y%m%d.log"), filepath.Join(dir, "app.log"),
+			&rotate{Interval: RotateDaily})
+		require.NoError(t, err)
+		w.now = func() time.Time { return now }
+		defer w.Close()
+
+		_, err = w.Write([]byte("day1\n"))
+		require.NoError(t, err)
+		now = now.Add(2 * time.Minute)
+		_, err = w.Write([]byte("day2\n"))
+		require.NoError(t, err)
+
+		bs, err := os.ReadFile(filepath.Join(dir, "app-20261016.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "day1\n", string(bs))
+		bs, err = os.ReadFile(filepath.Join(dir, "app-20261017.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "day2\n", string(bs))
+		if runtime.GOOS != "windows" {
+			target, err := os.Readlink(filepath.Join(dir, "app.log"))
+			require.NoError(t, err)
+			assert.Equal(t, "app-20261017.log", target)
+		}
+	})
+	t.Run("hourly", func(t *testing.T) {
+		dir := t.TempDir()
+		now := time.Date(2026, 10, 16, 8, 30, 0, 0, time.UTC)
+		w, err := newTimeRotateWriter(defaultRotatePattern(filepath.Join(dir, "app.log"), RotateHourly), "",
+			&rotate{Interval: RotateHourly})
+		require.NoError(t, err)
+		w.now = func() time.Time { return now }
+		defer w.Close()
+
+		_, err = w.Write([]byte("08\n"))
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+		_, err = w.Write([]byte("09\n"))
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(dir, "app-2026101608.log"))
+		assert.FileExists(t, filepath.Join(dir, "app-2026101609.log"))
+		assert.NoFileExists(t, filepath.Join(dir, "app.log"), "symlink is disabled")
+	})
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := newTimeRotateWriter(filepath.Join(t.TempDir(), "%Y", "app.log"), "", &rotate{Interval: RotateDaily})
+		assert.ErrorContains(t, err, "only allowed in the file name")
+		_, err = newTimeRotateWriter(filepath.Join(t.TempDir(), "app-%y.log"), "", &rotate{Interval: RotateDaily})
+		assert.ErrorContains(t, err, "unknown time verb")
+	})
+}
+
+func TestTimeRotateWriter_mill(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	// prepare creates the rotated files of the last days, every file is 1KB.
+	prepare := func(t *testing.T, days int) string {
+		dir := t.TempDir()
+		for i := days; i > 0; i-- {
+			day := now.AddDate(0, 0, -i)
+			name := filepath.Join(dir, formatPattern("app-%Y%m%d.log", day))
+			require.NoError(t, os.WriteFile(name, []byte(strings.Repeat("x", 1024)), 0644))
+			require.NoError(t, os.Chtimes(name, day, day))
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "other.log"), []byte("other"), 0644))
+		return dir
+	}
+	newWriter := func(t *testing.T, dir string, r *rotate) *timeRotateWriter {
+		r.Interval = RotateDaily
+		w, err := newTimeRotateWriter(filepath.Join(dir, "app-%Y%m%d.log"), "", r)
+		require.NoError(t, err)
+		w.now = func() time.Time { return now }
+		_, err = w.Write([]byte("current\n"))
+		require.NoError(t, err)
+		t.Cleanup(func() { w.Close() })
+		return w
+	}
+	list := func(t *testing.T, dir string) []string {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+
+	t.Run("compress", func(t *testing.T) {
+		dir := prepare(t, 2)
+		w := newWriter(t, dir, &rotate{Logger: lumberjack.Logger{Compress: true}})
+		require.NoError(t, w.millRunOnce())
+		assert.ElementsMatch(t, []string{"app-20261014.log.gz", "app-20261015.log.gz", "app-20261016.log", "other.log"},
+			list(t, dir))
+		f, err := os.Open(filepath.Join(dir, "app-20261015.log.gz"))
+		require.NoError(t, err)
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		bs, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Len(t, bs, 1024)
+		info, err := f.Stat()
+		require.NoError(t, err)
+		assert.Equal(t, now.AddDate(0, 0, -1).Unix(), info.ModTime().Unix(), "should keep the modification time")
+	})
+	t.Run("maxAge", func(t *testing.T) {
+		dir := prepare(t, 5)
+		w := newWriter(t, dir, &rotate{Logger: lumberjack.Logger{MaxAge: 3}})
+		require.NoError(t, w.millRunOnce())
+		assert.ElementsMatch(t, []string{"app-20261013.log", "app-20261014.log", "app-20261015.log",
+			"app-20261016.log", "other.log"}, list(t, dir))
+	})
+	t.Run("maxBackups", func(t *testing.T) {
+		dir := prepare(t, 5)
+		w := newWriter(t, dir, &rotate{Logger: lumberjack.Logger{MaxBackups: 1}})
+		require.NoError(t, w.millRunOnce())
+		assert.ElementsMatch(t, []string{"app-20261015.log", "app-20261016.log", "other.log"}, list(t, dir))
+	})
+	t.Run("maxTotalSize", func(t *testing.T) {
+		dir := prepare(t, 2048)
+		w := newWriter(t, dir, &rotate{MaxTotalSize: 1})
+		require.NoError(t, w.millRunOnce())
+		names := list(t, dir)
+		// 1MB contains the current file and 1023 rotated files.
+		assert.Len(t, names, 1+1023+1)
+		assert.Contains(t, names, "app-20261016.log")
+		assert.Contains(t, names, "app-20261015.log")
+		assert.NotContains(t, names, formatPattern("app-%Y%m%d.log", now.AddDate(0, 0, -1024)))
+	})
+}
+
+func TestConfig_TimeRotate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := conf.NewFromStringMap(map[string]any{
+		"disableSampling": true,
+		"cores": []any{
+			map[string]any{"level": "info", "outputPaths": []any{"app-%Y%m%d.log"}},
+			map[string]any{"level": "error", "outputPaths": []any{"app-%Y%m%d.log", "error.log"}},
+		},
+		"rotate": map[string]any{"interval": RotateDaily, "localtime": true, "symlink": true},
+	})
+	cfg.SetBaseDir(dir)
+	config, err := NewConfig(cfg)
+	require.NoError(t, err)
+	zl, err := config.BuildZap()
+	require.NoError(t, err)
+	zl.Info("info-message")
+	zl.Error("error-message")
+	require.NoError(t, zl.Sync())
+
+	timeRotateMu.Lock()
+	w := timeRotateWriters[filepath.Join(dir, "app-%Y%m%d.log")]
+	timeRotateMu.Unlock()
+	require.NotNil(t, w)
+	assert.Equal(t, 2, w.refs, "the cores should share the writer of the same file")
+
+	bs, err := os.ReadFile(filepath.Join(dir, formatPattern("app-%Y%m%d.log", time.Now())))
+	require.NoError(t, err)
+	assert.Contains(t, string(bs), "info-message")
+	assert.Equal(t, 2, strings.Count(string(bs), "error-message"))
+	bs, err = os.ReadFile(filepath.Join(dir, formatPattern("error-%Y%m%d.log", time.Now())))
+	require.NoError(t, err)
+	assert.Contains(t, string(bs), "error-message")
+	assert.NotContains(t, string(bs), "info-message")
+	if runtime.GOOS != "windows" {
+		target, err := os.Readlink(filepath.Join(dir, "error.log"))
+		require.NoError(t, err)
+		assert.Equal(t, formatPattern("error-%Y%m%d.log", time.Now()), target)
+	}
+
+	t.Run("invalid interval", func(t *testing.T) {
+		cfg := conf.NewFromStringMap(map[string]any{
+			"cores":  []any{map[string]any{"outputPaths": []any{"stdout"}}},
+			"rotate": map[string]any{"interval": "weekly"},
+		})
+		_, err := NewConfig(cfg)
+		assert.ErrorContains(t, err, "unknown rotate interval")
+	})
+}
@@ -0,0 +1,253 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler is a slog.Handler backed by Logger, so that the libraries logging by log/slog write to the cores of
+// the Logger. The attributes are converted to zap fields, the groups to nested objects, and the records are logged
+// by Logger.Ctx, so the ContextLogger such as the trace id injection and the ContextFieldsConfig take effect.
+//
+// The levels map to the nearest zap levels not above them: slog.LevelDebug and below to Debug, slog.LevelInfo to
+// Info, slog.LevelWarn to Warn and slog.LevelError and above to Error. The time of the record is not used, the
+// caller is taken from the PC of the record if the Logger adds the caller.
+type SlogHandler struct {
+	logger *Logger
+	// groups are the groups opened by WithGroup.
+	groups []string
+	// groupAttrs are the attributes added by WithAttrs to each group, it has the same length as groups.
+	groupAttrs [][]slog.Attr
+}
+
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// NewSlogHandler creates a slog.Handler backed by the Logger.
+func NewSlogHandler(l *Logger) *SlogHandler {
+	// the cores built by Config replace the caller under their sampling, other cores are wrapped once.
+	if _, ok := l.Core().(levelTee); !ok {
+		l = l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &slogCallerCore{Core: core}
+		}))
+	}
+	return &SlogHandler{
+		logger: l,
+	}
+}
+
+// AsSlogDefault installs the Logger as slog.Default by SlogHandler and returns the slog.Logger. After this call,
+// the output of the standard log package is logged by the Logger too, see slog.SetDefault.
+func (l *Logger) AsSlogDefault() *slog.Logger {
+	sl := slog.New(NewSlogHandler(l))
+	slog.SetDefault(sl)
+	return sl
+}
+
+// Enabled reports whether the Logger enables the level, the LevelOverride in the context is taken into account
+// for the Logger built by Config.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	lvl := SlogLevel(level)
+	if h.logger.Core().Enabled(lvl) {
+		return true
+	}
+	if o, ok := LevelOverrideFromContext(ctx); ok {
+		if _, ok := h.logger.Core().(levelTee); ok {
+			return o.Level.Enabled(lvl)
+		}
+	}
+	return false
+}
+
+// Handle logs the record by the ContextLogger of the Logger.
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	var fields []zap.Field
+	if len(h.groups) == 0 {
+		fields = make([]zap.Field, 0, len(attrs)+1)
+		for _, attr := range attrs {
+			if f, ok := slogAttrField(attr); ok {
+				fields = append(fields, f)
+			}
+		}
+	} else if f, ok := slogAttrField(h.groupAttr(attrs)); ok {
+		fields = make([]zap.Field, 1, 2)
+		fields[0] = f
+	}
+	l := h.logger
+	if o, ok := LevelOverrideFromContext(ctx); ok {
+		l = l.withLevelOverride(o.Level)
+	}
+	if record.PC != 0 {
+		fields = append(fields, zap.Field{Key: slogPCKey, Type: zapcore.SkipType, Integer: int64(record.PC)})
+	}
+	GetLoggerWithCtx(ctx, l).Log(SlogLevel(record.Level), record.Message, fields)
+	return nil
+}
+
+// WithAttrs returns a handler with the attributes, the attributes without group are added to the Logger by With.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	clone := *h
+	if len(h.groups) == 0 {
+		fields := make([]zap.Field, 0, len(attrs))
+		for _, attr := range attrs {
+			if f, ok := slogAttrField(attr); ok {
+				fields = append(fields, f)
+			}
+		}
+		clone.logger = h.logger.With(fields...)
+		return &clone
+	}
+	clone.groupAttrs = make([][]slog.Attr, len(h.groupAttrs))
+	copy(clone.groupAttrs, h.groupAttrs)
+	last := len(clone.groupAttrs) - 1
+	clone.groupAttrs[last] = append(clone.groupAttrs[last][:len(clone.groupAttrs[last]):len(clone.groupAttrs[last])], attrs...)
+	return &clone
+}
+
+// WithGroup returns a handler with the group, the following attributes are nested in it.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	clone.groupAttrs = append(h.groupAttrs[:len(h.groupAttrs):len(h.groupAttrs)], nil)
+	return &clone
+}
+
+// groupAttr nests the attributes of the record into the groups with the attributes added by WithAttrs.
+func (h *SlogHandler) groupAttr(attrs []slog.Attr) slog.Attr {
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		group := make([]slog.Attr, 0, len(h.groupAttrs[i])+len(attrs))
+		group = append(group, h.groupAttrs[i]...)
+		group = append(group, attrs...)
+		attrs = []slog.Attr{{Key: h.groups[i], Value: slog.GroupValue(group...)}}
+	}
+	return attrs[0]
+}
+
+// slogPCKey is the key of the skipped field carrying the PC of the slog record to slogCallerCore.
+const slogPCKey = "woocoo.slog.pc"
+
+// slogCallerCore replaces the caller of the entries with the caller of the slog record, so that the caller is right
+// whatever the frames of the ContextLogger are. The PC of the record is carried by a skipped field, so the core is
+// built once. The entries without caller or the PC field are kept as they are.
+type slogCallerCore struct {
+	zapcore.Core
+}
+
+func (c *slogCallerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &slogCallerCore{Core: c.Core.With(fields)}
+}
+
+func (c *slogCallerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *slogCallerCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !ent.Caller.Defined {
+		return c.Core.Write(ent, fields)
+	}
+	for i := len(fields) - 1; i >= 0; i-- {
+		if f := fields[i]; f.Type == zapcore.SkipType && f.Key == slogPCKey {
+			frame, _ := runtime.CallersFrames([]uintptr{uintptr(f.Integer)}).Next()
+			ent.Caller = zapcore.EntryCaller{Defined: true, PC: frame.PC, File: frame.File, Line: frame.Line,
+				Function: frame.Function}
+			break
+		}
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// SlogLevel converts the slog.Level to zapcore.Level.
+func SlogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// slogAttrField converts the attribute to zap field, it returns false if the attribute should be ignored.
+func slogAttrField(attr slog.Attr) (zap.Field, bool) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return zap.Field{}, false
+	}
+	v := attr.Value
+	switch v.Kind() {
+	case slog.KindString:
+		return zap.String(attr.Key, v.String()), true
+	case slog.KindInt64:
+		return zap.Int64(attr.Key, v.Int64()), true
+	case slog.KindUint64:
+		return zap.Uint64(attr.Key, v.Uint64()), true
+	case slog.KindFloat64:
+		return zap.Float64(attr.Key, v.Float64()), true
+	case slog.KindBool:
+		return zap.Bool(attr.Key, v.Bool()), true
+	case slog.KindDuration:
+		return zap.Duration(attr.Key, v.Duration()), true
+	case slog.KindTime:
+		return zap.Time(attr.Key, v.Time()), true
+	case slog.KindGroup:
+		group := slogGroup(v.Group())
+		if group.empty() {
+			return zap.Field{}, false
+		}
+		if attr.Key == "" {
+			return zap.Inline(group), true
+		}
+		return zap.Object(attr.Key, group), true
+	default:
+		if err, ok := v.Any().(error); ok {
+			return zap.NamedError(attr.Key, err), true
+		}
+		return zap.Any(attr.Key, v.Any()), true
+	}
+}
+
+// slogGroup is the attributes of a group.
+type slogGroup []slog.Attr
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, attr := range g {
+		if f, ok := slogAttrField(attr); ok {
+			f.AddTo(enc)
+		}
+	}
+	return nil
+}
+
+// empty reports whether the group has no attributes to log.
+func (g slogGroup) empty() bool {
+	for _, attr := range g {
+		if _, ok := slogAttrField(attr); ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/test/logtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type traceContextLogger struct{}
+
+func (traceContextLogger) LogFields(logger *Logger, ctx context.Context, lvl zapcore.Level, msg string, fields []zap.Field) {
+	if tid, ok := ctx.Value(TraceIDKey).(string); ok {
+		fields = append(fields, zap.String(logger.TraceIDKey, tid))
+	}
+	logger.Log(lvl, msg, fields...)
+}
+
+// deepContextLogger logs by the nested calls to check the caller.
+type deepContextLogger struct {
+	depth int
+}
+
+func (d *deepContextLogger) LogFields(logger *Logger, ctx context.Context, lvl zapcore.Level, msg string, fields []zap.Field) {
+	if d.depth == 0 {
+		logger.Log(lvl, msg, fields...)
+		return
+	}
+	(&deepContextLogger{depth: d.depth - 1}).LogFields(logger, ctx, lvl, msg, fields)
+}
+
+func newSlogTestLogger(buf *logtest.Buffer) *Logger {
+	ec := zap.NewProductionEncoderConfig()
+	ec.TimeKey = slog.TimeKey
+	ec.LevelKey = slog.LevelKey
+	ec.MessageKey = slog.MessageKey
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(ec), buf, zap.DebugLevel)
+	return New(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(CallerSkip)))
+}
+
+func TestSlogHandler_Conformance(t *testing.T) {
+	var buf *logtest.Buffer
+	slogtest.Run(t, func(t *testing.T) slog.Handler {
+		if strings.HasSuffix(t.Name(), "/zero-time") {
+			t.Skip("the time of the record is not used")
+		}
+		buf = &logtest.Buffer{}
+		return NewSlogHandler(newSlogTestLogger(buf))
+	}, func(t *testing.T) map[string]any {
+		var got map[string]any
+		require.NoError(t, json.Unmarshal([]byte(buf.LastLine()), &got))
+		return got
+	})
+}
+
+func TestSlogHandler(t *testing.T) {
+	buf := &logtest.Buffer{}
+	logger := newSlogTestLogger(buf)
+	logger.SetContextLogger(traceContextLogger{})
+	sl := slog.New(NewSlogHandler(logger))
+
+	t.Run("fields", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), TraceIDKey, "t1") //nolint:staticcheck
+		sl.With("app", "woocoo").WithGroup("req").With("id", 1).
+			InfoContext(ctx, "hello", "err", errors.New("broken"), slog.Group("user", "name", "u1"))
+		var got map[string]any
+		require.NoError(t, json.Unmarshal([]byte(buf.LastLine()), &got))
+		assert.Equal(t, "woocoo", got["app"])
+		assert.Equal(t, map[string]any{
+			"id": float64(1), "err": "broken", "user": map[string]any{"name": "u1"},
+		}, got["req"])
+		assert.Equal(t, "t1", got[TraceIDKey], "trace id should be added by the ContextLogger at top level")
+		assert.Contains(t, got["caller"], "log/slog_test.go")
+	})
+	t.Run("caller", func(t *testing.T) {
+		deep := newSlogTestLogger(buf)
+		deep.SetContextLogger(&deepContextLogger{depth: 3})
+		slog.New(NewSlogHandler(deep)).Info("deep")
+		var got map[string]any
+		require.NoError(t, json.Unmarshal([]byte(buf.LastLine()), &got))
+		assert.Contains(t, got["caller"], "log/slog_test.go")
+
+		pc, _, line, _ := runtime.Caller(0)
+		require.NoError(t, NewSlogHandler(logger).Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "pc", pc)))
+		got = nil
+		require.NoError(t, json.Unmarshal([]byte(buf.LastLine()), &got))
+		assert.Equal(t, fmt.Sprintf("log/slog_test.go:%d", line), got["caller"])
+
+		noCaller := New(zap.New(logger.Core()))
+		require.NoError(t, NewSlogHandler(noCaller).Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "no caller", pc)))
+		got = nil
+		require.NoError(t, json.Unmarshal([]byte(buf.LastLine()), &got))
+		assert.NotContains(t, got, "caller")
+	})
+	t.Run("level", func(t *testing.T) {
+		tests := []struct {
+			level slog.Level
+			want  zapcore.Level
+		}{
+			{slog.LevelDebug - 1, zapcore.DebugLevel},
+			{slog.LevelDebug, zapcore.DebugLevel},
+			{slog.LevelInfo, zapcore.InfoLevel},
+			{slog.LevelInfo + 1, zapcore.InfoLevel},
+			{slog.LevelWarn, zapcore.WarnLevel},
+			{slog.LevelError, zapcore.ErrorLevel},
+			{slog.LevelError + 4, zapcore.ErrorLevel},
+		}
+		for _, tt := range tests {
+			assert.Equal(t, tt.want, SlogLevel(tt.level), tt.level.String())
+		}
+		infoLogger := New(logger.WithOptions(zap.IncreaseLevel(zap.InfoLevel)).Logger)
+		h := NewSlogHandler(infoLogger)
+		assert.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+		assert.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+	})
+}
+
+func TestSlogHandler_Config(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "slog.log")
+	logger := NewFromConf(conf.NewFromStringMap(map[string]any{
+		"cores": []any{map[string]any{
+			"level": "info", "encoding": "json", "outputPaths": []any{file},
+			"sampling": map[string]any{"initial": 1, "thereafter": 100},
+		}},
+	}))
+	h := NewSlogHandler(logger)
+	assert.Same(t, logger, h.logger, "should not wrap the logger built by Config")
+	sl := slog.New(h)
+	lines := func() []string {
+		bs, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(file, 0))
+		return strings.Split(strings.TrimSpace(string(bs)), "\n")
+	}
+
+	_, _, line, _ := runtime.Caller(0)
+	for i := 0; i < 3; i++ {
+		sl.Info("sampled")
+	}
+	got := lines()
+	require.Len(t, got, 1, "should keep the sampling")
+	assert.Contains(t, got[0], fmt.Sprintf("log/slog_test.go:%d", line+2))
+	assert.NotContains(t, got[0], slogPCKey)
+
+	ctx := WithLevelOverride(context.Background(), LevelOverride{Level: zapcore.DebugLevel})
+	assert.True(t, h.Enabled(ctx, slog.LevelDebug))
+	sl.DebugContext(ctx, "override")
+	sl.Debug("debug")
+	got = lines()
+	require.Len(t, got, 1)
+	assert.Contains(t, got[0], `"override"`)
+}
+
+func TestLogger_AsSlogDefault(t *testing.T) {
+	old := slog.Default()
+	t.Cleanup(func() {
+		slog.SetDefault(old)
+	})
+	buf := &logtest.Buffer{}
+	sl := newSlogTestLogger(buf).AsSlogDefault()
+	assert.Same(t, sl, slog.Default())
+	slog.Warn("default", "k", "v")
+	assert.Contains(t, buf.LastLine(), `"level":"warn","time"`)
+	assert.Contains(t, buf.LastLine(), `"msg":"default","k":"v"`)
+}
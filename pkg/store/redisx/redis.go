@@ -1,6 +1,8 @@
 package redisx
 
 import (
+	"context"
+
 	"github.com/redis/go-redis/v9"
 	"github.com/tsingsun/woocoo/pkg/conf"
 )
@@ -158,6 +160,11 @@ func (c *Client) Close() error {
 	return c.UniversalClient.Close()
 }
 
+// Check pings the redis server, it can be used as a health checker.
+func (c *Client) Check(ctx context.Context) error {
+	return c.Ping(ctx).Err()
+}
+
 // Apply implements the conf.Configurable interface
 func (c *Client) Apply(cfg *conf.Configuration) error {
 	opts := redis.UniversalOptions{}
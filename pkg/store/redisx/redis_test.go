@@ -89,3 +89,12 @@ store:
 		})
 	}
 }
+
+func TestClient_Check(t *testing.T) {
+	rds := miniredis.RunT(t)
+	client, err := NewClient(conf.NewFromStringMap(map[string]any{"addrs": []string{rds.Addr()}}))
+	assert.NoError(t, err)
+	assert.NoError(t, client.Check(context.Background()))
+	rds.Close()
+	assert.Error(t, client.Check(context.Background()))
+}
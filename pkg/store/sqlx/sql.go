@@ -8,8 +8,7 @@ import (
 )
 
 const (
-	aesEnvKey = "DB_SECRET_KEY"
-	pwdTpl    = "${password}"
+	pwdTpl = "${password}"
 )
 
 type encryptionConfig struct {
@@ -39,7 +38,11 @@ type encryptionConfig struct {
 //	        password: U2FsdGVkX1+tlVEqk7q5J4HmwH0tZg
 //	        method: aes-gcm
 //
-// if use encrypted password, you need pass the env "DB_SECRET_KEY" which used AES-GCM encrypted by default
+// if use encrypted password, the method is looked up by conf.GetEncryptor, the default AES-GCM encryptor uses the key
+// of the env conf.SecretKeyEnv.
+// The secret references and encrypted values resolved by conf are also supported in dsn, such as:
+//
+//	dsn: root:ENC(aes-gcm:U2FsdGVkX1+tlVEqk7q5J4HmwH0tZg)@tcp(127.0.0.1:3306)
 func NewSqlDB(cfg *conf.Configuration) *sql.DB {
 	dsn := cfg.String("dsn")
 	if cfg.IsSet("encryption") {
@@ -78,8 +81,8 @@ func processDSN(cfg *conf.Configuration) (string, error) {
 		return original, nil
 	}
 
-	encryptor := encryptors[encConfig.Method]
-	if encryptor == nil {
+	encryptor, ok := conf.GetEncryptor(encConfig.Method)
+	if !ok {
 		return "", fmt.Errorf("encryptor %s not registered", encConfig.Method)
 	}
 	pwd, err := encryptor.Decrypt(encConfig.Password)
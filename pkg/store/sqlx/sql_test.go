@@ -82,7 +82,7 @@ testDriver:
     password: NDL8hS0DJAAupzRH99b1mvbjqTzpBUYsqIY8YBBszGDxtQ==
 `
 	t.Run("aes-gcm", func() {
-		t.Require().NoError(os.Setenv("DB_SECRET_KEY", "7d9f4e8b12c6a3f5e1b0d8c2a5f7e891"))
+		t.Require().NoError(os.Setenv(conf.SecretKeyEnv, "7d9f4e8b12c6a3f5e1b0d8c2a5f7e891"))
 		cfg := conf.NewFromBytes([]byte(config)).Load()
 		db := NewSqlDB(cfg.Sub("testDriver"))
 		t.NoError(db.Ping())
@@ -90,7 +90,7 @@ testDriver:
 		t.Contains(db.Driver().(*testDriver).dsn, expectedPwd)
 	})
 	t.Run("miss method", func() {
-		t.Require().NoError(os.Setenv("DB_SECRET_KEY", "7d9f4e8b12c6a3f5e1b0d8c2a5f7e891"))
+		t.Require().NoError(os.Setenv(conf.SecretKeyEnv, "7d9f4e8b12c6a3f5e1b0d8c2a5f7e891"))
 		cfg := conf.NewFromBytes([]byte(config)).Load()
 		cfg.Parser().Set("testDriver.encryption.method", "wrong")
 		t.Panics(func() {
@@ -104,9 +104,26 @@ testDriver:
 		t.NoError(db.Ping())
 		t.Contains(db.Driver().(*testDriver).dsn, pwdTpl)
 	})
+	t.Run("registered", func() {
+		RegisterEncryptor("sqlx-plain", plainEncryptor("fromSqlx"))
+		conf.RegisterEncryptor("plain", plainEncryptor("fromConf"))
+		cfg := conf.NewFromBytes([]byte(config)).Load()
+		cfg.Parser().Set("testDriver.encryption.method", "sqlx-plain")
+		db := NewSqlDB(cfg.Sub("testDriver"))
+		t.NoError(db.Ping())
+		t.Contains(db.Driver().(*testDriver).dsn, "root:fromSqlx@")
+		enc, ok := conf.GetEncryptor("sqlx-plain")
+		t.True(ok, "should register to conf")
+		t.Equal(plainEncryptor("fromSqlx"), enc)
+
+		cfg.Parser().Set("testDriver.encryption.method", "plain")
+		db = NewSqlDB(cfg.Sub("testDriver"))
+		t.NoError(db.Ping())
+		t.Contains(db.Driver().(*testDriver).dsn, "root:fromConf@")
+	})
 	t.Run("miss key", func() {
 		cfg := conf.NewFromBytes([]byte(config)).Load()
-		t.Require().NoError(os.Setenv("DB_SECRET_KEY", ""))
+		t.Require().NoError(os.Setenv(conf.SecretKeyEnv, ""))
 		t.Panics(func() {
 			NewSqlDB(cfg.Sub("testDriver"))
 		})
@@ -115,36 +132,47 @@ testDriver:
 
 func (t *testSuite) TestAesGcmEncryptor() {
 	t.Run("wrong key", func() {
-		enc := aesEncryptor{}
-		t.Require().NoError(os.Setenv("DB_SECRET_KEY", "wrongkey"))
+		enc := conf.AESEncryptor{}
+		t.Require().NoError(os.Setenv(conf.SecretKeyEnv, "wrongkey"))
 		cs, err := enc.Encrypt("123456")
 		t.Error(err)
 		t.Empty(cs)
 	})
 	t.Run("empty", func() {
-		t.Require().NoError(os.Setenv("DB_SECRET_KEY", ""))
-		enc := aesEncryptor{}
+		t.Require().NoError(os.Setenv(conf.SecretKeyEnv, ""))
+		enc := conf.AESEncryptor{}
 		cs, err := enc.Decrypt("1")
 		t.Error(err)
 		t.Empty(cs)
 	})
 	t.Run("normal", func() {
-		t.Require().NoError(os.Setenv("DB_SECRET_KEY", "7d9f4e8b12c6a3f5e1b0d8c2a5f7e891"))
-		enc := aesEncryptor{}
+		t.Require().NoError(os.Setenv(conf.SecretKeyEnv, "7d9f4e8b12c6a3f5e1b0d8c2a5f7e891"))
+		enc := conf.AESEncryptor{}
 		cs, _ := enc.Encrypt("123456")
 		t.NotEmpty(cs)
 	})
 	t.Run("decrypt", func() {
-		t.Require().NoError(os.Setenv("DB_SECRET_KEY", "7d9f4e8b12c6a3f5e1b0d8c2a5f7e891"))
-		enc := aesEncryptor{}
+		t.Require().NoError(os.Setenv(conf.SecretKeyEnv, "7d9f4e8b12c6a3f5e1b0d8c2a5f7e891"))
+		enc := conf.AESEncryptor{}
 		ed, _ := enc.Encrypt("123456")
 		ds, _ := enc.Decrypt(ed)
 		t.Equal("123456", ds)
 	})
 	t.Run("decrypt err", func() {
-		t.Require().NoError(os.Setenv("DB_SECRET_KEY", "7d9f4e8b12c6a3f5e1b0d8c2a5f7e891"))
-		enc := aesEncryptor{}
+		t.Require().NoError(os.Setenv(conf.SecretKeyEnv, "7d9f4e8b12c6a3f5e1b0d8c2a5f7e891"))
+		enc := conf.AESEncryptor{}
 		_, err := enc.Decrypt("*")
 		t.Error(err)
 	})
 }
+
+// plainEncryptor returns itself as the decrypted value.
+type plainEncryptor string
+
+func (p plainEncryptor) Encrypt(string) (string, error) {
+	return string(p), nil
+}
+
+func (p plainEncryptor) Decrypt(string) (string, error) {
+	return string(p), nil
+}
@@ -53,6 +53,7 @@ func registryIntegration() {
 	jwt := interceptor.JWT{}
 	aclog := interceptor.AccessLogger{}
 	recovery := interceptor.Recovery{}
+	loglevel := interceptor.LogLevel{}
 	compress := option.CompressionOption{}
 	optionsManager.so = map[string]ServerOptionFunc{
 		ka.Name():       ka.ServerOption,
@@ -63,11 +64,13 @@ func registryIntegration() {
 		jwt.Name():      jwt.UnaryServerInterceptor,
 		aclog.Name():    aclog.UnaryServerInterceptor,
 		recovery.Name(): recovery.UnaryServerInterceptor,
+		loglevel.Name(): loglevel.UnaryServerInterceptor,
 	}
 	optionsManager.ss = map[string]StreamServerInterceptorFunc{
 		jwt.Name():      jwt.SteamServerInterceptor,
 		aclog.Name():    aclog.StreamServerInterceptor,
 		recovery.Name(): recovery.StreamServerInterceptor,
+		loglevel.Name(): loglevel.StreamServerInterceptor,
 	}
 	optionsManager.cd = map[string]DialOptionFunc{
 		ka.Name():       ka.DialOption,
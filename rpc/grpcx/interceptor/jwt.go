@@ -6,6 +6,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/tsingsun/woocoo/pkg/auth"
 	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/pkg/log"
 	"github.com/tsingsun/woocoo/pkg/security"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
@@ -119,6 +120,8 @@ func (JWT) withToken(extractor ValuesExtractor, options *JWTOptions, ctx context
 		}
 		prpl := security.NewGenericPrincipalByClaims(claims)
 		newctx := security.WithContext(ctx, prpl)
+		// the access log outside can't see the principal, carry the fields to it.
+		log.AppendToIncomingContext(newctx, log.PrincipalFields(prpl)...)
 		return newctx, nil
 	}
 	if lastTokenErr != nil {
@@ -16,6 +16,8 @@ import (
 	"time"
 )
 
+const requestIDMetadataKey = "x-request-id"
+
 var (
 	defaultLoggerFormat = "grpc.start_time,grpc.service,grpc.method,grpc.request.deadline,status,error,latency," +
 		"peer.address"
@@ -40,6 +42,8 @@ type (
 		// option:
 		// - request
 		// - response
+		//
+		// The sensitive values in the fields are masked by the Redactor of the logger, see log.Config.Redact.
 		Format string `json:"format" yaml:"format"`
 		// AppendFormat is the format to append to the default logger format.
 		// out of default format:
@@ -139,14 +143,20 @@ func (al AccessLogger) UnaryServerInterceptor(cnf *conf.Configuration) grpc.Unar
 		}
 		carr, _ := log.FromIncomingContext(newCtx)
 		fields = append(fields, carr.Fields...)
+		fields = o.logger.Logger().RedactFields(fields)
 		o.logger.Ctx(newCtx).Log(level, msg, fields)
 		return resp, err
 	}
 }
 
-// init context with base fields for log method called.
+// init context with base fields for log method called, the request id in metadata is set to the context.
 func (al AccessLogger) newLoggerForCall(ctx context.Context) context.Context {
 	callLog := &log.FieldCarrier{}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if id := md.Get(requestIDMetadataKey); len(id) != 0 && id[0] != "" {
+			ctx = log.WithRequestID(ctx, id[0])
+		}
+	}
 	return log.NewIncomingContext(ctx, callLog)
 }
 
@@ -214,6 +224,7 @@ func (al AccessLogger) StreamServerInterceptor(cnf *conf.Configuration) grpc.Str
 		}
 		carr, _ := log.FromIncomingContext(newCtx)
 		fields = append(fields, carr.Fields...)
+		fields = o.logger.Logger().RedactFields(fields)
 		o.logger.Ctx(newCtx).Log(level, msg, fields)
 		return err
 	}
@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc/codes"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -54,6 +55,25 @@ func TestGrpcContextLogger(t *testing.T) {
 	assert.Contains(t, ls, `"response"`)
 }
 
+func TestAccessLogger_Redact(t *testing.T) {
+	logdata := wctest.InitBuffWriteSyncer()
+	log.Component(AccessLogComponentName).SetLogger(log.Global().Logger(log.WithOriginalLogger()))
+	clfg := conf.NewFromStringMap(map[string]any{
+		"appendFormat": "request,response",
+	})
+	gs, addr := testproto.NewPingGrpcService(t, grpc.ChainUnaryInterceptor(AccessLogger{}.UnaryServerInterceptor(clfg)))
+	defer gs.Stop()
+
+	conn, client := testproto.NewPingGrpcClient(t, context.Background(), addr)
+	defer conn.Close()
+	_, err := client.Ping(context.Background(), &testproto.PingRequest{Value: "password=p1"})
+	require.NoError(t, err)
+	ls := logdata.String()
+	assert.Contains(t, ls, `"request"`)
+	assert.NotContains(t, ls, "p1")
+	assert.Contains(t, ls, "password=******")
+}
+
 func TestLoggerUnaryServerInterceptor(t *testing.T) {
 	type fields struct {
 		ctx     context.Context
@@ -169,3 +189,30 @@ func TestDefaultCodeToLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestAccessLogger_ContextFields(t *testing.T) {
+	log.SetContextFields(&log.ContextFieldsConfig{UserID: "user_id", RequestID: "request_id"})
+	t.Cleanup(func() {
+		log.SetContextFields(nil)
+	})
+	logdata := &logtest.Buffer{}
+	log.Component(AccessLogComponentName).SetLogger(log.New(logtest.NewBuffLogger(logdata)))
+	accessLog := AccessLogger{}.UnaryServerInterceptor(conf.NewFromStringMap(map[string]any{}))
+	jwtItcp := JWT{}.UnaryServerInterceptor(Hs256TokenCnf)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"authorization", "Bearer "+hs256Token, requestIDMetadataKey, "r1"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/test/ping"}
+	_, err := accessLog(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+		return jwtItcp(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			log.Component(AccessLogComponentName).Ctx(ctx).Info("handler")
+			return nil, nil
+		})
+	})
+	require.NoError(t, err)
+	lines := logdata.Lines()
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Equal(t, 1, strings.Count(line, `"user_id":"1234567890"`), line)
+		assert.Contains(t, line, `"request_id":"r1"`)
+	}
+}
@@ -0,0 +1,65 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/pkg/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// LogLevel is the interceptor elevating the log level of a request by the metadata, the signed token or the claim
+// of the principal, see log.LevelElevationConfig. The metadata key is the lower case of the header.
+//
+// To elevate by the claim, the interceptor must be placed after the jwt interceptor.
+type LogLevel struct {
+}
+
+// Name returns the name of the interceptor.
+func (LogLevel) Name() string {
+	return "logLevel"
+}
+
+// UnaryServerInterceptor elevates the log level of the unary request.
+func (itcp LogLevel) UnaryServerInterceptor(cfg *conf.Configuration) grpc.UnaryServerInterceptor {
+	elevator := itcp.newElevator(cfg)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		return handler(itcp.withLevel(elevator, ctx), req)
+	}
+}
+
+// StreamServerInterceptor elevates the log level of the stream request.
+func (itcp LogLevel) StreamServerInterceptor(cfg *conf.Configuration) grpc.StreamServerInterceptor {
+	elevator := itcp.newElevator(cfg)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ws := WrapServerStream(ss)
+		ws.WrappedContext = itcp.withLevel(elevator, ss.Context())
+		return handler(srv, ws)
+	}
+}
+
+func (LogLevel) newElevator(cfg *conf.Configuration) *log.LevelElevator {
+	var config log.LevelElevationConfig
+	if err := cfg.Unmarshal(&config); err != nil {
+		panic(err)
+	}
+	elevator, err := log.NewLevelElevator(config)
+	if err != nil {
+		panic(err)
+	}
+	return elevator
+}
+
+func (LogLevel) withLevel(elevator *log.LevelElevator, ctx context.Context) context.Context {
+	var value string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vs := md.Get(elevator.Header()); len(vs) > 0 {
+			value = vs[0]
+		}
+	}
+	if o, ok := elevator.Check(ctx, value); ok {
+		return log.WithLevelOverride(ctx, o)
+	}
+	return ctx
+}
@@ -0,0 +1,74 @@
+package interceptor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/pkg/log"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestLogLevel(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "loglevel.log")
+	logger := log.NewFromConf(conf.NewFromStringMap(map[string]any{
+		"disableSampling": true,
+		"cores": []any{
+			map[string]any{"level": "info", "outputPaths": []any{file}},
+		},
+	}))
+	cl := log.Component("grpc-loglevel")
+	cl.SetLogger(logger)
+	readLog := func() string {
+		bs, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(file, 0))
+		return string(bs)
+	}
+	secret := "secret"
+	cfg := conf.NewFromStringMap(map[string]any{"secret": secret})
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want bool
+	}{
+		{name: "none"},
+		{name: "invalid token", md: metadata.Pairs("x-debug-log", "1")},
+		{name: "signed token", md: metadata.Pairs("x-debug-log",
+			log.SignLevelToken([]byte(secret), zapcore.DebugLevel, time.Now().Add(time.Minute))), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			_, err := LogLevel{}.UnaryServerInterceptor(cfg)(ctx, nil, &grpc.UnaryServerInfo{},
+				func(ctx context.Context, req any) (any, error) {
+					cl.Ctx(ctx).Debug("unary")
+					return nil, nil
+				})
+			require.NoError(t, err)
+			err = LogLevel{}.StreamServerInterceptor(cfg)(nil, &WrappedServerStream{WrappedContext: ctx}, &grpc.StreamServerInfo{},
+				func(srv any, ss grpc.ServerStream) error {
+					cl.Ctx(ss.Context()).Debug("stream")
+					return nil
+				})
+			require.NoError(t, err)
+			got := readLog()
+			if !tt.want {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Contains(t, got, "unary")
+			assert.Contains(t, got, "stream")
+		})
+	}
+}
@@ -14,11 +14,16 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
 
-var logger = log.Component(log.GrpcComponentName)
+var (
+	logger = log.Component(log.GrpcComponentName)
+
+	ErrServerNotReady = errors.New("grpcx: server is not ready")
+)
 
 const (
 	defaultPort    = 9080
@@ -56,10 +61,14 @@ type Server struct {
 
 	registry     registry.Registry
 	registryDone bool
+	// registryErrs holds the error of the last registration by service name, it is used by Check.
+	registryErrs sync.Map
 	// ServiceInfos is for service discovery, it converts from grpc service info
 	ServiceInfos []*registry.ServiceInfo
 
 	mu sync.RWMutex
+	// ready indicates whether the server is listening and services are registered
+	ready atomic.Bool
 }
 
 // New creates a new grpc server.
@@ -160,43 +169,51 @@ func (s *Server) ListenAndServe() (err error) {
 		}
 		for _, serviceInfo := range s.ServiceInfos {
 			if err := s.registry.Register(serviceInfo); err != nil {
+				s.registryErrs.Store(serviceInfo.Name, err)
 				s.deregisterServices() // deregister all services if one fails
 				return err
 			}
+			s.registryErrs.Delete(serviceInfo.Name)
 		}
+		// keep registering by the ttl only if any service is registered, Drain stops it.
 		if len(s.ServiceInfos) > 0 {
 			s.mu.Lock()
 			s.registryDone = true
 			s.mu.Unlock()
-		}
-		go func() {
-			t := new(time.Ticker)
-			// only process if it exists
-			if s.registry.TTL() > time.Duration(0) {
-				// new ticker
-				t = time.NewTicker(s.registry.TTL())
-			}
-			var ch chan error
-			for {
-				select {
-				case <-t.C:
-					for _, serviceInfo := range s.ServiceInfos {
-						go func(info *registry.ServiceInfo) {
-							if err := s.registry.Register(info); err != nil {
-								grpclog.Errorf("grpcx: failed to register %s:%d to service %s(%s) at ttl: %v",
-									info.Host, info.Port, info.Name, info.Namespace, err)
-							}
-						}(serviceInfo)
+			go func() {
+				t := new(time.Ticker)
+				// only process if it exists
+				if s.registry.TTL() > time.Duration(0) {
+					// new ticker
+					t = time.NewTicker(s.registry.TTL())
+				}
+				var ch chan error
+				for {
+					select {
+					case <-t.C:
+						for _, serviceInfo := range s.ServiceInfos {
+							go func(info *registry.ServiceInfo) {
+								if err := s.registry.Register(info); err != nil {
+									s.registryErrs.Store(info.Name, err)
+									grpclog.Errorf("grpcx: failed to register %s:%d to service %s(%s) at ttl: %v",
+										info.Host, info.Port, info.Name, info.Namespace, err)
+								} else {
+									s.registryErrs.Delete(info.Name)
+								}
+							}(serviceInfo)
+						}
+					case ch = <-s.exit:
+						t.Stop()
+						s.deregisterServices()
+						s.registryErrs.Clear()
+						ch <- err
+						return
 					}
-				case ch = <-s.exit:
-					t.Stop()
-					s.deregisterServices()
-					ch <- err
-					return
 				}
-			}
-		}()
+			}()
+		}
 	}
+	s.ready.Store(true)
 	// grpc Serve run, it will return a non-nil error unless Stop or WithGracefulStop is called.
 	// so director check err
 	err = s.engine.Serve(s.opts.listener)
@@ -223,20 +240,62 @@ func (s *Server) Start(_ context.Context) error {
 	return s.ListenAndServe()
 }
 
-func (s *Server) Stop(_ context.Context) (err error) {
-	if s.registry != nil {
-		s.mu.RLock()
-		defer s.mu.RUnlock()
-		if s.registryDone {
-			ch := make(chan error)
-			s.exit <- ch
-			err = <-ch
-		}
+// Ready implements woocoo.Readiness, the server is ready after it starts listening and services are registered.
+func (s *Server) Ready(context.Context) error {
+	if !s.ready.Load() {
+		return ErrServerNotReady
+	}
+	return nil
+}
+
+// Check implements admin.Checker, it reports the errors of the last registration of the services to registry.
+// It is always healthy if the registry is not used.
+func (s *Server) Check(context.Context) error {
+	var errs []error
+	s.registryErrs.Range(func(name, err any) bool {
+		errs = append(errs, fmt.Errorf("register %s: %w", name, err.(error)))
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+// Drain implements woocoo.Drainer, it marks the server not ready and deregisters the services from registry.
+// The server still serves requests until Stop.
+func (s *Server) Drain(context.Context) (err error) {
+	s.ready.Store(false)
+	if s.registry == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.registryDone {
+		ch := make(chan error)
+		s.exit <- ch
+		err = <-ch
+		s.registryDone = false
 	}
-	if s.opts.gracefulStop {
+	return err
+}
+
+// Stop drains the server if not yet, then stops it. If graceful stop is used, the server is stopped forcibly
+// when ctx is done.
+func (s *Server) Stop(ctx context.Context) error {
+	err := s.Drain(ctx)
+	if !s.opts.gracefulStop {
+		s.engine.Stop()
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
 		s.engine.GracefulStop()
-	} else {
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.Warn("grpc server graceful stop timeout, force stop")
 		s.engine.Stop()
+		<-done
 	}
 	return err
 }
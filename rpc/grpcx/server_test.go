@@ -12,16 +12,21 @@ import (
 	"github.com/tsingsun/woocoo/test/testdata"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/test/bufconn"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 type MockRegistry struct {
+	unregistered atomic.Int32
+	// failing makes the registration at ttl fail.
+	failing atomic.Bool
+	ttl     time.Duration
 }
 
 // Register a service node
 func (mr *MockRegistry) Register(serviceInfo *registry.ServiceInfo) error {
-	if serviceInfo.Name == "error" {
+	if serviceInfo.Name == "error" || mr.failing.Load() {
 		return errors.New("register error")
 	}
 	return nil
@@ -32,12 +37,16 @@ func (mr *MockRegistry) Unregister(serviceInfo *registry.ServiceInfo) error {
 	if serviceInfo.Name == "error" {
 		return errors.New("unregister error")
 	}
+	mr.unregistered.Add(1)
 	return nil
 }
 
 // TTL returns the time to live of the service node, if it is not available, return 0.
 // every tick will call Register function to refresh.
 func (mr *MockRegistry) TTL() time.Duration {
+	if mr.ttl > 0 {
+		return mr.ttl
+	}
 	return time.Second
 }
 func (mr *MockRegistry) Close() {
@@ -143,6 +152,9 @@ func TestServer_Run(t *testing.T) {
 			}
 			if tt.service {
 				helloworld.RegisterGreeterServer(s.Engine(), &helloworld.Server{})
+				time.AfterFunc(time.Second, func() {
+					assert.NoError(t, s.Ready(context.Background()))
+				})
 			}
 			time.AfterFunc(time.Second*2, func() {
 				s.Stop(context.Background())
@@ -152,6 +164,108 @@ func TestServer_Run(t *testing.T) {
 	}
 }
 
+func TestServer_Drain(t *testing.T) {
+	rg := &MockRegistry{}
+	s := &Server{
+		opts: serverOptions{
+			Addr:         "127.0.0.1:0",
+			gracefulStop: true,
+		},
+		engine:   grpc.NewServer(),
+		registry: rg,
+		exit:     make(chan chan error),
+	}
+	helloworld.RegisterGreeterServer(s.Engine(), &helloworld.Server{})
+	done := make(chan error)
+	go func() {
+		done <- s.Start(context.Background())
+	}()
+	assert.Eventually(t, func() bool {
+		return s.Ready(context.Background()) == nil
+	}, time.Second, time.Millisecond*10)
+
+	assert.NoError(t, s.Drain(context.Background()))
+	assert.ErrorIs(t, s.Ready(context.Background()), ErrServerNotReady)
+	assert.EqualValues(t, 1, rg.unregistered.Load())
+	// drain twice and stop will not deregister again
+	assert.NoError(t, s.Drain(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, s.Stop(ctx))
+	assert.EqualValues(t, 1, rg.unregistered.Load())
+	assert.NoError(t, <-done)
+}
+
+func TestServer_Check(t *testing.T) {
+	rg := &MockRegistry{ttl: time.Millisecond * 50}
+	s := &Server{
+		opts: serverOptions{
+			Addr: "127.0.0.1:0",
+		},
+		engine:   grpc.NewServer(),
+		registry: rg,
+		exit:     make(chan chan error),
+	}
+	helloworld.RegisterGreeterServer(s.Engine(), &helloworld.Server{})
+	done := make(chan error)
+	go func() {
+		done <- s.Start(context.Background())
+	}()
+	assert.Eventually(t, func() bool {
+		return s.Ready(context.Background()) == nil
+	}, time.Second, time.Millisecond*10)
+	assert.NoError(t, s.Check(context.Background()))
+
+	rg.failing.Store(true)
+	assert.Eventually(t, func() bool {
+		return s.Check(context.Background()) != nil
+	}, time.Second, time.Millisecond*10)
+	assert.ErrorContains(t, s.Check(context.Background()), "register helloworld.Greeter: register error")
+	rg.failing.Store(false)
+	assert.Eventually(t, func() bool {
+		return s.Check(context.Background()) == nil
+	}, time.Second, time.Millisecond*10)
+
+	assert.NoError(t, s.Stop(context.Background()))
+	assert.NoError(t, <-done)
+
+	t.Run("initial", func(t *testing.T) {
+		rg := &MockRegistry{}
+		rg.failing.Store(true)
+		s := &Server{
+			opts:     serverOptions{Addr: "127.0.0.1:0"},
+			engine:   grpc.NewServer(),
+			registry: rg,
+			exit:     make(chan chan error),
+		}
+		helloworld.RegisterGreeterServer(s.Engine(), &helloworld.Server{})
+		assert.ErrorContains(t, s.Start(context.Background()), "register error")
+		assert.ErrorContains(t, s.Check(context.Background()), "register helloworld.Greeter: register error",
+			"should record the initial registration")
+		assert.False(t, s.registryDone)
+	})
+	t.Run("no services", func(t *testing.T) {
+		s := &Server{
+			opts:     serverOptions{Addr: "127.0.0.1:0"},
+			engine:   grpc.NewServer(),
+			registry: &MockRegistry{},
+			exit:     make(chan chan error),
+		}
+		done := make(chan error)
+		go func() {
+			done <- s.Start(context.Background())
+		}()
+		assert.Eventually(t, func() bool {
+			return s.Ready(context.Background()) == nil
+		}, time.Second, time.Millisecond*10)
+		s.mu.Lock()
+		assert.False(t, s.registryDone, "should not keep registering without services")
+		s.mu.Unlock()
+		assert.NoError(t, s.Stop(context.Background()))
+		assert.NoError(t, <-done)
+	})
+}
+
 func TestRunWithOption(t *testing.T) {
 	t.Run("listener", func(t *testing.T) {
 		b := []byte(`
@@ -7,9 +7,9 @@ import (
 	"time"
 )
 
-// CORS Cross-Origin Resource Sharing (CORS) support
+// CORS Cross-Origin Resource Sharing (CORS) support. It is reloadable, the changed origins take effect at runtime.
 func CORS() Middleware {
-	return NewSimpleMiddleware(CORSName, func(cnf *conf.Configuration) gin.HandlerFunc {
+	return NewReloadableMiddleware(CORSName, func(cnf *conf.Configuration) gin.HandlerFunc {
 		config := cors.Config{
 			AllowMethods:    []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
 			AllowHeaders:    []string{"Origin", "Content-Length", "Content-Type"},
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -65,6 +66,8 @@ type LoggerConfig struct {
 	// - form:<NAME>
 	// - context:<NAME>
 	//
+	// The sensitive values such as the Authorization header and the password in the request body are masked
+	// by the Redactor of the logger, see log.Config.Redact.
 	//
 	// Optional. Default value DefaultLoggerConfig.Format.
 	Format string `json:"format" yaml:"format"`
@@ -112,15 +115,27 @@ func (h *LoggerConfig) BuildTag(format string) {
 type LoggerMiddleware struct {
 	config LoggerConfig
 	logger log.ComponentLogger
+	// current is the config in use with its logger, it is swapped when reloading.
+	current atomic.Pointer[accessLogState]
+}
+
+// accessLogState is the config and the logger built by it.
+type accessLogState struct {
+	config LoggerConfig
+	logger *log.Logger
 }
 
 // NewAccessLog a new LoggerMiddleware,it is for handler registry
 func NewAccessLog() *LoggerMiddleware {
 	return &LoggerMiddleware{
-		config: LoggerConfig{
-			Format: defaultLoggerFormat,
-			Level:  zapcore.InvalidLevel,
-		},
+		config: newLoggerConfig(),
+	}
+}
+
+func newLoggerConfig() LoggerConfig {
+	return LoggerConfig{
+		Format: defaultLoggerFormat,
+		Level:  zapcore.InvalidLevel,
 	}
 }
 
@@ -137,47 +152,30 @@ func (h *LoggerMiddleware) Name() string {
 // zap.AddStacktrace(zapcore.FatalLevel + 1) force to not add stack.
 // zap.WithCaller(false) accessLog no need to.
 func (h *LoggerMiddleware) buildLogger() {
-	opts := []zap.Option{
-		zap.AddStacktrace(zapcore.FatalLevel + 1), zap.WithCaller(false),
-	}
-	if h.config.Level != zapcore.InvalidLevel {
-		opts = append(opts, zap.IncreaseLevel(h.config.Level))
-	}
 	olog := logger.Logger(log.WithOriginalLogger())
-	operator := olog.WithOptions(opts...)
+	operator := olog.WithOptions(zap.AddStacktrace(zapcore.FatalLevel+1), zap.WithCaller(false))
 	h.logger = log.Component(AccessLogComponentName)
 	h.logger.SetLogger(operator)
 }
 
 // ApplyFunc build a gin.HandlerFunc for NewAccessLog middleware
 func (h *LoggerMiddleware) ApplyFunc(cfg *conf.Configuration) gin.HandlerFunc {
-	if err := cfg.Unmarshal(&h.config); err != nil {
-		panic(err)
-	}
-	format := h.config.Format + "," + h.config.AppendFormat
-	h.config.BuildTag(format)
 	h.buildLogger()
-	if h.config.Skipper == nil && len(h.config.Exclude) > 0 {
-		pm := StringsToMap(h.config.Exclude)
-		h.config.Skipper = func(c *gin.Context) bool {
-			// if it has error,should log
-			if len(c.Errors) > 0 {
-				return false
-			}
-			return PathSkip(pm, c.Request.URL)
-		}
-	} else {
-		h.config.Skipper = DefaultSkipper
+	if err := h.applyConfig(cfg, h.config); err != nil {
+		panic(err)
 	}
-	traceIDKey := h.logger.Logger().TraceIDKey
-	logBodyIn := h.config.logBodyIn
 	return func(c *gin.Context) {
+		state := h.current.Load()
+		config := &state.config
 		start := time.Now()
 		logCarrier := log.NewCarrier()
 		c.Set(AccessLogComponentName, logCarrier)
+		if id := c.GetHeader("X-Request-Id"); id != "" {
+			c.Set(log.RequestIDContextKey, id)
+		}
 
 		var body []byte
-		if logBodyIn {
+		if config.logBodyIn {
 			if c.Request.Body != nil && c.Request.Body != http.NoBody {
 				body, _ = io.ReadAll(c.Request.Body)
 				c.Request.Body = io.NopCloser(bytes.NewReader(body))
@@ -185,7 +183,7 @@ func (h *LoggerMiddleware) ApplyFunc(cfg *conf.Configuration) gin.HandlerFunc {
 		}
 
 		c.Next()
-		if h.config.Skipper(c) {
+		if config.Skipper(c) {
 			return
 		}
 
@@ -194,13 +192,13 @@ func (h *LoggerMiddleware) ApplyFunc(cfg *conf.Configuration) gin.HandlerFunc {
 		latency := time.Now().Sub(start)
 		path := c.Request.URL.Path
 
-		fields := make([]zap.Field, len(h.config.Tags))
+		fields := make([]zap.Field, len(config.Tags))
 		privateErr := false
-		for i, tag := range h.config.Tags {
+		for i, tag := range config.Tags {
 			switch tag.FullKey {
 			case "id":
 				id := req.Header.Get("X-Request-Id")
-				fields[i] = zap.String(traceIDKey, id)
+				fields[i] = zap.String(state.logger.TraceIDKey, id)
 			case "remoteIp":
 				fields[i] = zap.String("remoteIp", c.ClientIP())
 			case "host":
@@ -296,7 +294,8 @@ func (h *LoggerMiddleware) ApplyFunc(cfg *conf.Configuration) gin.HandlerFunc {
 				}
 			}
 		}
-		clog := h.logger.Ctx(c)
+		fields = state.logger.RedactFields(fields)
+		clog := log.NewLoggerWithCtx(c, state.logger)
 		if privateErr {
 			clog.Error("", fields...)
 		} else {
@@ -305,6 +304,42 @@ func (h *LoggerMiddleware) ApplyFunc(cfg *conf.Configuration) gin.HandlerFunc {
 	}
 }
 
+// Reload implements Reloadable, the changed format, exclude paths and level take effect at runtime.
+func (h *LoggerMiddleware) Reload(cfg *conf.Configuration) error {
+	return h.applyConfig(cfg, newLoggerConfig())
+}
+
+// applyConfig unmarshal the configuration base on the config, and swap it with the logger of its level to the
+// current state. The component logger is not changed, so the requests in flight keep using the old state.
+func (h *LoggerMiddleware) applyConfig(cfg *conf.Configuration, config LoggerConfig) error {
+	if err := cfg.Unmarshal(&config); err != nil {
+		return err
+	}
+	format := config.Format + "," + config.AppendFormat
+	config.BuildTag(format)
+	if config.Skipper == nil && len(config.Exclude) > 0 {
+		pm := StringsToMap(config.Exclude)
+		config.Skipper = func(c *gin.Context) bool {
+			// if it has error,should log
+			if len(c.Errors) > 0 {
+				return false
+			}
+			return PathSkip(pm, c.Request.URL)
+		}
+	} else {
+		config.Skipper = DefaultSkipper
+	}
+	state := &accessLogState{
+		config: config,
+		logger: h.logger.Logger(log.WithContextLogger()),
+	}
+	if config.Level != zapcore.InvalidLevel {
+		state.logger = state.logger.WithOptions(zap.IncreaseLevel(config.Level))
+	}
+	h.current.Store(state)
+	return nil
+}
+
 // GetLogCarrierFromGinContext get log.FieldCarrier from gin.Context
 func GetLogCarrierFromGinContext(c *gin.Context) *log.FieldCarrier {
 	if fc, ok := c.Get(AccessLogComponentName); ok {
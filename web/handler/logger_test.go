@@ -8,9 +8,11 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/tsingsun/woocoo/pkg/conf"
 	"github.com/tsingsun/woocoo/pkg/log"
+	"github.com/tsingsun/woocoo/pkg/security"
 	"github.com/tsingsun/woocoo/test/logtest"
 	"github.com/tsingsun/woocoo/test/wctest"
 	"go.uber.org/zap"
@@ -222,6 +224,40 @@ func TestLoggerMiddleware(t *testing.T) {
 				ss := i[0].(*logtest.Buffer)
 				all := ss.String()
 				assert.Contains(t, all, `testuser`)
+				assert.NotContains(t, all, `testpass`)
+				return true
+			},
+		},
+		{
+			name: "redact",
+			args: args{
+				cfg: conf.NewFromStringMap(map[string]any{
+					"format": "uri,header:Authorization,header:accept",
+				}),
+				request: func() *http.Request {
+					r := httptest.NewRequest("GET", "/?access_token=t1&q=1&orderId=4111111111111111", nil)
+					r.Header.Set("Authorization", "Bearer b1")
+					r.Header.Set("Accept", "text/plain")
+					return r
+				}(),
+				handler: func(c *gin.Context) {
+					fc := GetLogCarrierFromGinContext(c)
+					fc.Fields = append(fc.Fields, zap.String("password", "p1"))
+				},
+			},
+			want: func() any {
+				logdata := wctest.InitBuffWriteSyncer()
+				return logdata
+			},
+			wantErr: func(t assert.TestingT, err error, i ...any) bool {
+				ss := i[0].(*logtest.Buffer)
+				all := ss.String()
+				assert.NotContains(t, all, "t1")
+				assert.NotContains(t, all, "b1")
+				assert.NotContains(t, all, "p1")
+				assert.Contains(t, all, `"header:accept":"text/plain"`)
+				assert.Contains(t, all, `"header:Authorization":"******"`)
+				assert.Contains(t, all, "orderId=4111111111111111")
 				return true
 			},
 		},
@@ -333,6 +369,35 @@ func TestLoggerMiddleware(t *testing.T) {
 				return true
 			},
 		},
+		{
+			name: "context fields",
+			args: args{
+				cfg: conf.NewFromStringMap(map[string]any{}),
+				request: func() *http.Request {
+					r := httptest.NewRequest("GET", "/", nil)
+					r.Header.Set("X-Request-Id", "r1")
+					return r
+				}(),
+				handler: func(c *gin.Context) {
+					c.Set(security.PrincipalContextKey, security.NewGenericPrincipalByClaims(jwt.MapClaims{"sub": "1"}))
+					log.Component(log.WebComponentName).Ctx(c).Info("handler")
+				},
+			},
+			want: func() any {
+				log.SetContextFields(&log.ContextFieldsConfig{UserID: "user_id", RequestID: "request_id"})
+				return wctest.InitBuffWriteSyncer()
+			},
+			wantErr: func(t assert.TestingT, err error, i ...any) bool {
+				log.SetContextFields(nil)
+				lines := i[0].(*logtest.Buffer).Lines()
+				assert.Len(t, lines, 2)
+				for _, line := range lines {
+					assert.Contains(t, line, `"user_id":"1"`)
+					assert.Contains(t, line, `"request_id":"r1"`)
+				}
+				return true
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -362,3 +427,37 @@ func TestLoggerMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestLoggerMiddleware_Reload(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	buf := wctest.InitBuffWriteSyncer()
+	accessLog := NewAccessLog()
+	srv := gin.New()
+	srv.Use(accessLog.ApplyFunc(conf.NewFromStringMap(map[string]any{})))
+	srv.GET("/", func(c *gin.Context) {})
+	component := log.Component(AccessLogComponentName).Logger()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, accessLog.Reload(conf.NewFromStringMap(map[string]any{"format": "path"})))
+	}
+	<-done
+	assert.Same(t, component, log.Component(AccessLogComponentName).Logger(), "reload should not change the component")
+
+	assert.NoError(t, accessLog.Reload(conf.NewFromStringMap(map[string]any{"level": "error"})))
+	buf.Reset()
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Empty(t, buf.Lines())
+
+	assert.NoError(t, accessLog.Reload(conf.NewFromStringMap(map[string]any{"format": "method"})))
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if assert.Len(t, buf.Lines(), 1) {
+		assert.Contains(t, buf.Lines()[0], `"method":"GET"`)
+	}
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/pkg/log"
+)
+
+// LogLevel elevates the log level of a request by the header, the signed token or the claim of the principal,
+// see log.LevelElevationConfig. The loggers created by Ctx of the request context log at the elevated level.
+//
+// To elevate by the claim, the middleware must be placed after the jwt middleware. It is reloadable.
+func LogLevel() Middleware {
+	return NewReloadableMiddleware(LogLevelName, func(cnf *conf.Configuration) gin.HandlerFunc {
+		var config log.LevelElevationConfig
+		if err := cnf.Unmarshal(&config); err != nil {
+			panic(err)
+		}
+		elevator, err := log.NewLevelElevator(config)
+		if err != nil {
+			panic(err)
+		}
+		return func(c *gin.Context) {
+			o, ok := elevator.Check(GetDerivativeContext(c), c.GetHeader(elevator.Header()))
+			if !ok {
+				return
+			}
+			c.Set(log.LevelOverrideContextKey, o)
+			DerivativeContextWithValue(c, log.LevelOverrideContextKey, o)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/pkg/log"
+	"github.com/tsingsun/woocoo/pkg/security"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogLevel(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	file := filepath.Join(t.TempDir(), "loglevel.log")
+	logger := log.NewFromConf(conf.NewFromStringMap(map[string]any{
+		"disableSampling": true,
+		"cores": []any{
+			map[string]any{"level": "info", "outputPaths": []any{file}},
+		},
+	}))
+	cl := log.Component("web-loglevel")
+	cl.SetLogger(logger)
+	readLog := func() string {
+		bs, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(file, 0))
+		return string(bs)
+	}
+	secret := "secret"
+	tests := []struct {
+		name   string
+		header string
+		claims jwt.MapClaims
+		want   bool
+	}{
+		{name: "none"},
+		{name: "untrusted header", header: "1"},
+		{name: "signed token", header: log.SignLevelToken([]byte(secret), zapcore.DebugLevel, time.Now().Add(time.Minute)), want: true},
+		{name: "claim", claims: jwt.MapClaims{"debug_log": true}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.ContextWithFallback = true
+			router.Use(func(c *gin.Context) {
+				if tt.claims != nil {
+					DerivativeContextWithValue(c, security.PrincipalContextKey, security.NewGenericPrincipalByClaims(tt.claims))
+				}
+			})
+			router.Use(LogLevel().ApplyFunc(conf.NewFromStringMap(map[string]any{
+				"secret": secret,
+				"claim":  "debug_log",
+			})))
+			router.GET("/", func(c *gin.Context) {
+				cl.Ctx(c).Debug("gin context")
+				cl.Ctx(c.Request.Context()).Debug("request context")
+			})
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				r.Header.Set(log.DefaultLevelHeader, tt.header)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), r)
+			got := readLog()
+			if !tt.want {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Contains(t, got, "gin context")
+			assert.Contains(t, got, "request context")
+		})
+	}
+}
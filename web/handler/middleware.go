@@ -2,11 +2,13 @@ package handler
 
 import (
 	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/tsingsun/woocoo/pkg/conf"
 	"github.com/tsingsun/woocoo/pkg/log"
 	"net/url"
 	"strings"
+	"sync/atomic"
 )
 
 var (
@@ -22,6 +24,7 @@ const (
 	KeyAuthName      = "keyAuth"
 	CORSName         = "cors"
 	CSRFName         = "csrf"
+	LogLevelName     = "logLevel"
 )
 
 // Middleware is an instance to build middleware for web application.
@@ -37,6 +40,13 @@ type Shutdown interface {
 	Shutdown(ctx context.Context) error
 }
 
+// Reloadable is an optional interface of Middleware, which applies the changed configuration at runtime.
+type Reloadable interface {
+	// Reload applies the new configuration of the middleware, the handler function returned by ApplyFunc
+	// should take effect with it.
+	Reload(cfg *conf.Configuration) error
+}
+
 // MiddlewareApplyFunc defines a function to initial new middleware by a configuration
 type MiddlewareApplyFunc func(cfg *conf.Configuration) gin.HandlerFunc
 
@@ -76,6 +86,43 @@ func (s *SimpleMiddleware) ApplyFunc(cfg *conf.Configuration) gin.HandlerFunc {
 	return s.applyFunc(cfg)
 }
 
+// ReloadableMiddleware is a SimpleMiddleware that rebuilds the handler function by the apply function when reloading.
+type ReloadableMiddleware struct {
+	SimpleMiddleware
+	handler atomic.Pointer[gin.HandlerFunc]
+}
+
+// NewReloadableMiddleware returns a new ReloadableMiddleware instance.
+func NewReloadableMiddleware(name string, applyFunc MiddlewareApplyFunc) *ReloadableMiddleware {
+	return &ReloadableMiddleware{
+		SimpleMiddleware: SimpleMiddleware{
+			name:      name,
+			applyFunc: applyFunc,
+		},
+	}
+}
+
+// ApplyFunc returns a handler function which calls the current handler built by the apply function.
+func (r *ReloadableMiddleware) ApplyFunc(cfg *conf.Configuration) gin.HandlerFunc {
+	hf := r.applyFunc(cfg)
+	r.handler.Store(&hf)
+	return func(c *gin.Context) {
+		(*r.handler.Load())(c)
+	}
+}
+
+// Reload rebuilds the handler function by the new configuration.
+func (r *ReloadableMiddleware) Reload(cfg *conf.Configuration) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("reload middleware %s: %v", r.name, e)
+		}
+	}()
+	hf := r.applyFunc(cfg)
+	r.handler.Store(&hf)
+	return nil
+}
+
 // Skipper defines a function to skip middleware. Returning true skips processing
 // the middleware.
 type Skipper func(c *gin.Context) bool
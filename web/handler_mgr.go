@@ -72,6 +72,7 @@ func integration() map[string]handler.MiddlewareNewFunc {
 		handler.GZipName:         gzip.Gzip,
 		handler.KeyAuthName:      handler.KeyAuth,
 		handler.CORSName:         handler.CORS,
+		handler.LogLevelName:     handler.LogLevel,
 	}
 	return handlerMap
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/tsingsun/woocoo/pkg/conf"
+	"github.com/tsingsun/woocoo/web/handler"
 )
 
 // RouterGroup is a wrapper for gin.RouterGroup.
@@ -80,6 +81,29 @@ func (r *Router) Apply(cnf *conf.Configuration) (err error) {
 	return nil
 }
 
+// Reload applies the changed configuration of the engine to the middlewares which implement handler.Reloadable.
+// The router groups and the middlewares can not be added or removed at runtime.
+func (r *Router) Reload(cnf *conf.Configuration) (err error) {
+	cnf.Each("routerGroups", func(group string, sub *conf.Configuration) {
+		gr := r.FindGroup(sub.String("basePath"))
+		if gr == nil {
+			return
+		}
+		sub.Each("middlewares", func(name string, cfg *conf.Configuration) {
+			mw, ok := r.serverOptions.handlerManager.GetMiddleware(GetMiddlewareKey(gr.Group.BasePath(), name))
+			if !ok {
+				return
+			}
+			if rl, ok := mw.(handler.Reloadable); ok {
+				if rerr := rl.Reload(cfg); rerr != nil {
+					err = errors.Join(err, rerr)
+				}
+			}
+		})
+	})
+	return err
+}
+
 // FindGroup return a specified router group by an url format base path.
 //
 // parameter basePath is map to configuration:
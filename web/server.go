@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -22,10 +23,12 @@ const (
 
 var (
 	logger = log.Component(log.WebComponentName)
+
+	ErrServerNotReady = errors.New("web: server is not ready")
 )
 
 type ServerOptions struct {
-	Addr           string              `json:"addr" yaml:"addr"`
+	Addr           string              `json:"addr" yaml:"addr" validate:"required" default:":8080"`
 	TLS            *conf.TLS           `json:"tls" yaml:"tls"`
 	configuration  *conf.Configuration // not root configuration
 	handlerManager *HandlerManager     // middleware manager
@@ -41,6 +44,8 @@ type Server struct {
 	router *Router
 	// low level
 	httpSrv *http.Server
+	// ready indicates whether the server is listening
+	ready atomic.Bool
 }
 
 // New create a web server
@@ -61,6 +66,7 @@ func New(opts ...Option) *Server {
 		if err := s.Apply(s.opts.configuration); err != nil {
 			panic(err)
 		}
+		s.opts.configuration.Subscribe("engine", s.reload)
 	}
 	s.httpSrv = &http.Server{
 		Addr:    s.opts.Addr,
@@ -86,7 +92,7 @@ func (s *Server) Router() *Router {
 // Apply implement conf.Configuration
 func (s *Server) Apply(cfg *conf.Configuration) error {
 	if k := "server"; cfg.IsSet(k) {
-		if err := cfg.Parser().Unmarshal(k, &s.opts); err != nil {
+		if err := cfg.Sub(k).Unmarshal(&s.opts); err != nil {
 			return err
 		}
 	}
@@ -101,6 +107,16 @@ func (s *Server) Apply(cfg *conf.Configuration) error {
 	return nil
 }
 
+// reload applies the changed engine configuration to the reloadable middlewares.
+func (s *Server) reload(_, cnf *conf.Configuration) {
+	if cnf == nil {
+		return
+	}
+	if err := s.router.Reload(cnf); err != nil {
+		logger.Error("web server reload configuration err", zap.Error(err))
+	}
+}
+
 func (s *Server) Start(ctx context.Context) error {
 	err := s.ListenAndServe()
 	if err != nil && errors.Is(err, http.ErrServerClosed) {
@@ -109,8 +125,25 @@ func (s *Server) Start(ctx context.Context) error {
 	return err
 }
 
-// Stop http server and clear resource
+// Ready implements woocoo.Readiness, the server is ready after it starts listening.
+func (s *Server) Ready(context.Context) error {
+	if !s.ready.Load() {
+		return ErrServerNotReady
+	}
+	return nil
+}
+
+// Drain implements woocoo.Drainer, it marks the server not ready and disables keep-alives, so that clients
+// reconnect to other instances. The server still serves requests until Stop.
+func (s *Server) Drain(context.Context) error {
+	s.ready.Store(false)
+	s.httpSrv.SetKeepAlivesEnabled(false)
+	return nil
+}
+
+// Stop http server and clear resource. If graceful stop is used, the server is closed forcibly when ctx is done.
 func (s *Server) Stop(ctx context.Context) error {
+	s.ready.Store(false)
 	err := s.httpServerStop(ctx)
 	if err != nil {
 		logger.Error("web Server close err", zap.Error(err))
@@ -135,6 +168,7 @@ func (s *Server) ListenAndServe() (err error) {
 	}
 	s.opts.Addr = s.opts.listener.Addr().String()
 	logger.Info(fmt.Sprintf("listening and serving HTTP on %s", s.opts.Addr))
+	s.ready.Store(true)
 	if s.opts.TLS != nil {
 		err = s.httpSrv.ServeTLS(s.opts.listener, s.opts.TLS.Cert, s.opts.TLS.Key)
 	} else {
@@ -186,6 +220,10 @@ func (s *Server) Run() error {
 func (s *Server) httpServerStop(ctx context.Context) (err error) {
 	if s.opts.gracefulStop {
 		err = s.httpSrv.Shutdown(ctx)
+		if err != nil && ctx.Err() != nil {
+			logger.Warn("web server graceful shutdown timeout, force close", zap.Error(err))
+			err = s.httpSrv.Close()
+		}
 	} else {
 		err = s.httpSrv.Close()
 	}
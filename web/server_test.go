@@ -2,12 +2,17 @@ package web
 
 import (
 	"context"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tsingsun/woocoo/pkg/conf"
 	"github.com/tsingsun/woocoo/test/testdata"
 	"github.com/tsingsun/woocoo/test/wctest"
@@ -160,6 +165,7 @@ web:
 			}, func() error {
 				if !wantErr {
 					time.Sleep(time.Millisecond * 100)
+					assert.NoError(t, srv.Ready(context.Background()))
 					return srv.Stop(context.Background())
 				}
 				return nil
@@ -173,3 +179,76 @@ web:
 		})
 	}
 }
+
+func TestServer_Drain(t *testing.T) {
+	srv := New(WithConfiguration(conf.NewFromStringMap(map[string]any{
+		"server": map[string]any{"addr": "127.0.0.1:0"},
+	})), WithGracefulStop())
+	started := make(chan struct{})
+	srv.Router().GET("/slow", func(c *gin.Context) {
+		close(started)
+		time.Sleep(time.Second * 2)
+		c.String(200, "slow")
+	})
+	done := make(chan error)
+	go func() {
+		done <- srv.Start(context.Background())
+	}()
+	assert.Eventually(t, func() bool {
+		return srv.Ready(context.Background()) == nil
+	}, time.Second, time.Millisecond*10)
+	go http.Get("http://" + srv.ServerOptions().Addr + "/slow") //nolint:errcheck
+	<-started
+
+	assert.NoError(t, srv.Drain(context.Background()))
+	assert.ErrorIs(t, srv.Ready(context.Background()), ErrServerNotReady)
+	// in-flight request exceeds the stop timeout, force close
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	begin := time.Now()
+	assert.NoError(t, srv.Stop(ctx))
+	assert.Less(t, time.Since(begin), time.Second)
+	assert.NoError(t, <-done)
+}
+
+func TestServer_ApplyStrict(t *testing.T) {
+	cnf := conf.NewFromBytes([]byte(`
+web:
+  server:
+    adr: 127.0.0.1:0
+`), conf.WithStrict())
+	assert.PanicsWithError(t, "conf: unknown keys: web.server.adr", func() {
+		New(WithConfiguration(cnf.Sub("web")))
+	})
+}
+
+func TestServer_Reload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	cfgStr := `
+web:
+  engine:
+    routerGroups:
+      - default:
+          middlewares:
+            - cors:
+                allowOrigins:
+                  - "https://woocoo.com"
+`
+	require.NoError(t, os.WriteFile(file, []byte(cfgStr), 0600))
+	cnf := conf.New(conf.WithBaseDir(dir), conf.WithLocalPath(file)).Load()
+	srv := New(WithConfiguration(cnf.Sub("web")))
+	srv.Router().GET("/", func(c *gin.Context) {})
+	request := func() int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Origin", "https://github.com")
+		w := httptest.NewRecorder()
+		srv.Router().ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusForbidden, request())
+
+	require.NoError(t, os.WriteFile(file, []byte(strings.Replace(cfgStr, "woocoo.com", "github.com", 1)), 0600))
+	require.NoError(t, cnf.TryReload())
+	assert.Equal(t, http.StatusOK, request())
+}